@@ -6,6 +6,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v2"
 	"os"
+	"time"
 )
 
 func main() {
@@ -21,42 +22,297 @@ func main() {
 			{"Fredrik Forsmo", "hello@frozzare.com"},
 			{"Johannes Tegnér", "johannes@jitesoft.com"},
 		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Usage:   "configuration file (default to ~/.gopkg.yaml)",
+				EnvVars: []string{"GOPKG_CONFIG"},
+			},
+		},
+		Before: cmd.SetConfigPath,
 		Commands: []*cli.Command{
 			{
 				Name:      "make",
 				Usage:     "create a new package from import-path",
 				ArgsUsage: "import-path",
-				Action:    cmd.ExecMake,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "update",
+						Usage: "update existing package to the latest upstream release",
+					},
+					&cli.BoolFlag{
+						Name:  "group",
+						Usage: "group the binaries in packages by directory (f.e foo & foo-extras) instead of a package per binary",
+					},
+				},
+				Action: cmd.ExecMake,
 			},
 			{
 				Name:      "build",
 				Usage:     "build a package from control directory/package",
 				ArgsUsage: "control-path",
-				Action:    cmd.ExecBuild,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "pristine-source",
+						Usage: "build the source package without applying the patches",
+					},
+					&cli.IntFlag{
+						Name:    "jobs",
+						Aliases: []string{"j"},
+						Usage:   "number of targets built concurrently (default to the number of CPUs)",
+					},
+					&cli.BoolFlag{
+						Name:  "fail-fast",
+						Usage: "stop building at the first failing target",
+					},
+					&cli.BoolFlag{
+						Name:  "verify-reproducible",
+						Usage: "build twice and make sure the packages are identical",
+					},
+					&cli.StringFlag{
+						Name:  "profile",
+						Usage: "name of the build profile to use",
+					},
+					&cli.BoolFlag{
+						Name:  "hermetic",
+						Usage: "build offline in an isolated environment only containing the build dependencies",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "directory where the packages are saved",
+						Value:   ".",
+					},
+					&cli.BoolFlag{
+						Name:  "skip-tests",
+						Usage: "do not run the upstream tests",
+					},
+					&cli.BoolFlag{
+						Name:  "no-cache",
+						Usage: "rebuild every binary package without using the build cache",
+					},
+				},
+				Action: cmd.ExecBuild,
+			},
+			{
+				Name:      "lint",
+				Usage:     "check a control directory for common mistakes",
+				ArgsUsage: "control-path",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "output the issues as JSON",
+					},
+				},
+				Action: cmd.ExecLint,
+			},
+			{
+				Name:      "schema",
+				Usage:     "print the JSON schema of a control file",
+				ArgsUsage: "metadata|changelog",
+				Action:    cmd.ExecSchema,
+			},
+			{
+				Name:  "changelog",
+				Usage: "manage the control package changelog",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "new-release",
+						Usage:     "add a new release (bump the package revision unless --upstream is set)",
+						ArgsUsage: "[control-path]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "upstream",
+								Usage: "new upstream version",
+							},
+							&cli.StringFlag{
+								Name:  "distribution",
+								Usage: "distribution of the release (default to the previous release one)",
+							},
+							&cli.StringFlag{
+								Name:  "urgency",
+								Usage: "urgency of the release (low, medium, high, critical)",
+							},
+						},
+						Action: cmd.ExecChangelogNewRelease,
+					},
+					{
+						Name:      "add",
+						Usage:     "add a change to the latest release",
+						ArgsUsage: "message [control-path]",
+						Action:    cmd.ExecChangelogAdd,
+					},
+				},
+			},
+			{
+				Name:  "patch",
+				Usage: "manage the patches applied on top of upstream source",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "new",
+						Usage:     "create a new patch from the current changes",
+						ArgsUsage: "patch-name [control-path]",
+						Action:    cmd.ExecPatchNew,
+					},
+					{
+						Name:      "refresh",
+						Usage:     "update the topmost patch with the current changes",
+						ArgsUsage: "[patch-name] [control-path]",
+						Action:    cmd.ExecPatchRefresh,
+					},
+					{
+						Name:      "apply",
+						Usage:     "apply the patch series",
+						ArgsUsage: "[control-path]",
+						Action:    cmd.ExecPatchApply,
+					},
+					{
+						Name:      "revert",
+						Usage:     "revert the applied patch series",
+						ArgsUsage: "[control-path]",
+						Action:    cmd.ExecPatchRevert,
+					},
+				},
+			},
+			{
+				Name:  "build-cache",
+				Usage: "manage the build cache",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "prune",
+						Usage: "remove the cached packages not used recently",
+						Flags: []cli.Flag{
+							&cli.DurationFlag{
+								Name:  "max-age",
+								Usage: "remove the packages not used since this duration",
+								Value: 30 * 24 * time.Hour,
+							},
+							&cli.BoolFlag{
+								Name:  "all",
+								Usage: "remove every cached package",
+							},
+						},
+						Action: cmd.ExecBuildCachePrune,
+					},
+				},
 			},
 			{
 				Name:      "install",
 				Usage:     "install a package from path",
 				ArgsUsage: "pkg-path",
-				Action:    cmd.ExecInstall,
+				Flags: append([]cli.Flag{
+					&cli.BoolFlag{
+						Name:  "no-scripts",
+						Usage: "do not run the package hooks",
+					},
+				}, cmd.ScopeFlags...),
+				Action: cmd.ExecInstall,
 			},
 			{
 				Name:      "remove",
 				Usage:     "remove installed package",
 				ArgsUsage: "pkg-name",
-				Action:    cmd.ExecRemove,
+				Flags: append([]cli.Flag{
+					&cli.BoolFlag{
+						Name:  "no-scripts",
+						Usage: "do not run the package hooks",
+					},
+				}, cmd.ScopeFlags...),
+				Action: cmd.ExecRemove,
+			},
+			{
+				Name:      "watch",
+				Usage:     "report control packages having newer upstream versions",
+				ArgsUsage: "control-path...",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "source",
+						Usage: "where to look for upstream versions (git, proxy)",
+						Value: "git",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "output the report as JSON",
+					},
+				},
+				Action: cmd.ExecWatch,
 			},
 			{
 				Name:  "list",
 				Usage: "list packages",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.BoolFlag{
 						Name:  "installed",
 						Usage: "list only installed packages",
 					},
-				},
+				}, cmd.ScopeFlags...),
 				Action: cmd.ExecList,
 			},
+			{
+				Name:      "info",
+				Usage:     "display how a control package was built",
+				ArgsUsage: "pkg-path",
+				Action:    cmd.ExecInfo,
+			},
+			{
+				Name:  "config",
+				Usage: "view & change the configuration",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "get",
+						Usage:     "display the effective value of a key",
+						ArgsUsage: "key",
+						Action:    cmd.ExecConfigGet,
+					},
+					{
+						Name:      "set",
+						Usage:     "set a value in the configuration file (the file comments are not kept, use edit to keep them)",
+						ArgsUsage: "key value",
+						Action:    cmd.ExecConfigSet,
+					},
+					{
+						Name:   "list",
+						Usage:  "list the effective values & where they come from (one key, source & value per line, tab separated)",
+						Action: cmd.ExecConfigList,
+					},
+					{
+						Name:   "edit",
+						Usage:  "open the configuration file in $EDITOR",
+						Action: cmd.ExecConfigEdit,
+					},
+					{
+						Name:   "path",
+						Usage:  "display the configuration file path",
+						Action: cmd.ExecConfigPath,
+					},
+				},
+			},
+			{
+				Name:  "env",
+				Usage: "display the environment variables to use the installed packages",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:  "shell",
+						Usage: "shell syntax (sh, fish or powershell), detected if not set",
+					},
+					&cli.BoolFlag{
+						Name:  "check",
+						Usage: "warn if the bin directories are not in PATH",
+					},
+				}, cmd.ScopeFlags...),
+				Action: cmd.ExecEnv,
+			},
+			{
+				Name:  "shell-init",
+				Usage: "display the line to add to the shell rc file",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "shell",
+						Usage: "shell syntax (sh, fish or powershell), detected if not set",
+					},
+				},
+				Action: cmd.ExecShellInit,
+			},
 		},
 	}
 
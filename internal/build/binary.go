@@ -0,0 +1,452 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/pkg"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v2"
+)
+
+// target is a binary package to build for a given target
+type target struct {
+	pkg control.Package
+	control.Target
+}
+
+// getTargets returns the targets of given packages resolved using given profile (may be nil)
+// targets are sorted so the build output is deterministic
+func getTargets(pkgs []control.Package, profile *control.Profile) ([]target, error) {
+	var targets []target
+
+	for _, p := range pkgs {
+		pkgTargets, err := p.GetTargets(profile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid targets for %s: %s", p.Alias, err)
+		}
+
+		for _, t := range pkgTargets {
+			targets = append(targets, target{pkg: p, Target: t})
+		}
+	}
+
+	return targets, nil
+}
+
+// buildBinaryPackages build the binary packages of given packages using a pool of workers
+// failures are reported once every target has been built, unless fail fast is enabled
+func (b *builder) buildBinaryPackages() error {
+	targets, err := getTargets(b.metadata.Packages, b.profile)
+	if err != nil {
+		return err
+	}
+
+	if b.hooks, err = control.ReadHooks(b.path); err != nil {
+		return err
+	}
+
+	// The build cache is useless when verifying reproducibility
+	if !b.opts.NoCache && !b.opts.VerifyReproducible {
+		if b.cache, err = newBuildCache(b); err != nil {
+			return err
+		}
+	}
+
+	jobs := b.opts.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pkgNames := make([]string, len(targets))
+	errs := make([]error, len(targets))
+
+	queue := make(chan int)
+	wg := sync.WaitGroup{}
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range queue {
+				pkgNames[i], errs[i] = b.buildBinaryPackage(ctx, targets[i])
+				if errs[i] != nil && b.opts.FailFast {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for i := range targets {
+		// Stop scheduling targets once cancelled
+		if ctx.Err() != nil {
+			errs[i] = ctx.Err()
+			continue
+		}
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	// Report in targets order
+	var failures []string
+	for i, t := range targets {
+		if errs[i] == nil {
+			log.Info().Str("package", pkgNames[i]).Msg("Successfully built binary package")
+			continue
+		}
+
+		name := fmt.Sprintf("%s (%s)", t.pkg.Alias, t.Name())
+		if errs[i] == context.Canceled {
+			log.Warn().Str("target", name).Msg("Target build cancelled")
+		} else {
+			log.Error().Str("target", name).Str("err", errs[i].Error()).Msg("Error while building target")
+		}
+		failures = append(failures, name)
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d target(s) failed to build: %s", len(failures), strings.Join(failures, ", "))
+	}
+
+	return nil
+}
+
+// buildBinaryPackage build the binary package of given target
+// the go build output is saved in the target log file
+// binaries are built in a reproducible way (no build paths nor build id)
+// this method returns the package name
+func (b *builder) buildBinaryPackage(ctx context.Context, t target) (string, error) {
+	p := t.pkg
+
+	pkgName, err := pkg.GetFileName(p.Alias, b.release.Version, t.Os, t.PkgArch(), pkg.Binary)
+	if err != nil {
+		return "", err
+	}
+
+	targetDir := filepath.Join(b.buildDir, pkgName)
+
+	logPath := filepath.Join(b.buildDir, logsDir, strings.TrimSuffix(pkgName, "."+pkg.FileExt)+".log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return "", err
+	}
+	defer logFile.Close()
+
+	args, env, err := b.getBuildArgs(t)
+	if err != nil {
+		return "", err
+	}
+
+	relations, err := getRelationsFile(p)
+	if err != nil {
+		return "", err
+	}
+
+	// Reuse the cached package if the inputs are unchanged
+	var cacheKey string
+	if b.cache != nil {
+		cacheKey = b.cache.key(p.Alias, binariesSpec(p), pkgName, t.Os, t.Arch,
+			strings.Join(args, " "), strings.Join(env, "\n"), string(relations), hooksSpec(b.hooks), filesSpec(p))
+
+		path := filepath.Join(b.outputDir, pkgName)
+		hit, err := b.cache.get(cacheKey, path)
+		if err != nil {
+			return "", err
+		}
+		if hit {
+			log.Info().Str("package", pkgName).Msg("Using cached binary package")
+			b.addPackage(path)
+			return pkgName, nil
+		}
+	}
+
+	binaries := p.GetBinaries()
+	for _, binary := range binaries {
+		if err := b.buildBinary(ctx, t, binary, args, env, targetDir, logFile, logPath); err != nil {
+			return "", err
+		}
+	}
+
+	// Create the alias file
+	// this is used later on to determinate which package we are installing
+	if err := ioutil.WriteFile(filepath.Join(targetDir, "alias"), []byte(p.Alias), 0640); err != nil {
+		return "", err
+	}
+
+	entries := []pkg.Entry{
+		// Add the alias file
+		{
+			FilePath:    filepath.Join(targetDir, "alias"),
+			ArchivePath: "alias",
+		},
+	}
+
+	// Add the binaries
+	for _, binary := range binaries {
+		entries = append(entries, pkg.Entry{
+			FilePath:    filepath.Join(targetDir, binary.BinName),
+			ArchivePath: filepath.Join("bin", binary.BinName),
+		})
+	}
+
+	// Add the relations file if any
+	if relations != nil {
+		if err := ioutil.WriteFile(filepath.Join(targetDir, control.RelationsFile), relations, 0640); err != nil {
+			return "", err
+		}
+		entries = append(entries, pkg.Entry{
+			FilePath:    filepath.Join(targetDir, control.RelationsFile),
+			ArchivePath: control.RelationsFile,
+		})
+	}
+
+	// Add the extra files if any
+	files, err := b.getExtraFiles(p)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range files {
+		filePath := filepath.Join(targetDir, filepath.FromSlash(f.archivePath))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0750); err != nil {
+			return "", err
+		}
+		if err := ioutil.WriteFile(filePath, f.content, 0640); err != nil {
+			return "", err
+		}
+		entries = append(entries, pkg.Entry{FilePath: filePath, ArchivePath: f.archivePath})
+	}
+
+	// Add the hooks if any
+	for _, name := range control.Hooks {
+		script, exist := b.hooks[name]
+		if !exist {
+			continue
+		}
+
+		hookPath := filepath.Join(targetDir, control.HooksDir, name)
+		if err := os.MkdirAll(filepath.Dir(hookPath), 0750); err != nil {
+			return "", err
+		}
+		if err := ioutil.WriteFile(hookPath, script, 0750); err != nil {
+			return "", err
+		}
+		entries = append(entries, pkg.Entry{
+			FilePath:    hookPath,
+			ArchivePath: filepath.Join(control.HooksDir, name),
+		})
+	}
+
+	// Save the package in output directory
+	if err := b.writePackage(pkgName, entries); err != nil {
+		return "", err
+	}
+
+	if b.cache != nil {
+		if err := b.cache.put(cacheKey, filepath.Join(b.outputDir, pkgName)); err != nil {
+			log.Warn().Str("package", pkgName).Str("err", err.Error()).Msg("Error while caching binary package")
+		}
+	}
+
+	// Remove the build file and keep package.
+	if err := os.RemoveAll(targetDir); err != nil {
+		return "", err
+	}
+
+	return pkgName, nil
+}
+
+// buildBinary build the given binary of the target package in targetDir
+// the go build output is appended to the target log file
+func (b *builder) buildBinary(ctx context.Context, t target, binary control.Binary, args, env []string,
+	targetDir string, logFile *os.File, logPath string) error {
+	args = append(args, "-o", filepath.Join(targetDir, binary.BinName), binary.Main)
+
+	// Remember where the output of this binary starts
+	offset, err := logFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	log.Trace().Msgf("Executing `%s`", cmd.String())
+	cmd.Dir = b.path
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.Env = append(b.getGoEnv(), fmt.Sprintf("GOOS=%s", t.Os), fmt.Sprintf("GOARCH=%s", t.Arch))
+	cmd.Env = append(cmd.Env, env...)
+	err = cmd.Run()
+
+	// Record the target output in the build log
+	output, readErr := ioutil.ReadFile(logPath)
+	if readErr == nil && int64(len(output)) > offset {
+		output = output[offset:]
+		b.writeLog(nil, append([]byte(fmt.Sprintf("==> %s (%s) %s\n", t.pkg.Alias, t.Name(), binary.BinName)), output...))
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if importErr := b.checkUndeclaredImports(output, err); importErr != err {
+			return importErr
+		}
+		return fmt.Errorf("%s (see %s)", err, logPath)
+	}
+
+	return nil
+}
+
+// binariesSpec returns the binaries of given package in a textual form (used as build cache input)
+func binariesSpec(p control.Package) string {
+	var sb strings.Builder
+	for _, binary := range p.GetBinaries() {
+		fmt.Fprintf(&sb, "binary %s %s\n", binary.BinName, binary.Main)
+	}
+
+	return sb.String()
+}
+
+// getRelationsFile returns the content of the relations file of given package
+// nil is returned if the package has no relations
+func getRelationsFile(p control.Package) ([]byte, error) {
+	relations := p.Relations()
+	if err := relations.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid relations for %s: %s", p.Alias, err)
+	}
+
+	if len(relations.Depends)+len(relations.Recommends)+len(relations.Provides)+len(relations.Conflicts) == 0 {
+		return nil, nil
+	}
+
+	return yaml.Marshal(relations)
+}
+
+// hooksSpec returns the hook scripts in a textual form (used as build cache input)
+func hooksSpec(hooks map[string][]byte) string {
+	var sb strings.Builder
+	for _, name := range control.Hooks {
+		if script, exist := hooks[name]; exist {
+			fmt.Fprintf(&sb, "hook %s %d\n%s\n", name, len(script), script)
+		}
+	}
+
+	return sb.String()
+}
+
+// templateData are the variables available in the package ldflags & env
+type templateData struct {
+	// Version is the upstream version (f.e 1.2.0)
+	Version string
+	// Release is the package version (f.e 1.2.0-1)
+	Release string
+	// Commit is the upstream commit, if known
+	Commit string
+	// Date is the release date (RFC3339)
+	Date    string
+	Os      string
+	Arch    string
+	Variant string
+}
+
+// getBuildArgs returns the go build arguments & the extra environment variables
+// used to build given target
+func (b *builder) getBuildArgs(t target) ([]string, []string, error) {
+	data := templateData{
+		Version: b.release.UpstreamVersion(),
+		Release: b.release.Version,
+		Commit:  b.commit,
+		Date:    b.modTime.UTC().Format(time.RFC3339),
+		Os:      t.Os,
+		Arch:    t.Arch,
+		Variant: t.Variant,
+	}
+
+	// Merge the package, profile & target settings
+	ldFlags := t.pkg.LDFlags
+	tags := append([]string{}, t.pkg.Tags...)
+	envs := []map[string]string{t.pkg.Env}
+	if b.profile != nil {
+		ldFlags = strings.TrimSpace(ldFlags + " " + b.profile.LDFlags)
+		tags = append(tags, b.profile.Tags...)
+		envs = append(envs, b.profile.Env)
+	}
+	tags = append(tags, t.Tags...)
+	envs = append(envs, t.Env)
+
+	ldFlags, err := renderTemplate(ldFlags, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid ldflags: %s", err)
+	}
+
+	// Build in a reproducible way (no build paths nor build id)
+	args := []string{"build", "-trimpath", strings.TrimSpace("-ldflags=-buildid= " + ldFlags)}
+	if len(tags) > 0 {
+		args = append(args, "-tags", strings.Join(tags, ","))
+	}
+
+	var env []string
+	if t.pkg.Cgo != nil {
+		if *t.pkg.Cgo {
+			env = append(env, "CGO_ENABLED=1")
+		} else {
+			env = append(env, "CGO_ENABLED=0")
+		}
+	}
+	if variantEnv := t.VariantEnv(); variantEnv != "" {
+		env = append(env, variantEnv)
+	}
+
+	merged := map[string]string{}
+	for _, e := range envs {
+		for key, value := range e {
+			merged[key] = value
+		}
+	}
+
+	// Sort the variables to keep the build deterministic
+	var keys []string
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, err := renderTemplate(merged[key], data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid env %s: %s", key, err)
+		}
+		env = append(env, fmt.Sprintf("%s=%s", key, value))
+	}
+
+	return args, env, nil
+}
+
+func renderTemplate(text string, data templateData) (string, error) {
+	tpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err := tpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,96 @@
+package build
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+)
+
+func TestGetTargets(t *testing.T) {
+	pkgs := []control.Package{
+		{Alias: "foo", Targets: map[string][]string{"linux": {"arm64", "amd64"}, "darwin": {"amd64"}}},
+		{Alias: "bar", Targets: map[string][]string{"windows": {"amd64"}}},
+	}
+
+	expected := []string{"foo/darwin/amd64", "foo/linux/amd64", "foo/linux/arm64", "bar/windows/amd64"}
+
+	// Run several times since map iteration order is random
+	for i := 0; i < 10; i++ {
+		targets, err := getTargets(pkgs, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(targets) != len(expected) {
+			t.Fatalf("wrong number of targets (%d)", len(targets))
+		}
+
+		for i, target := range targets {
+			if name := target.pkg.Alias + "/" + target.Name(); name != expected[i] {
+				t.Errorf("wrong target at %d (got: %s want: %s)", i, name, expected[i])
+			}
+		}
+	}
+}
+
+func TestGetBuildArgs(t *testing.T) {
+	cgo := false
+	b := &builder{
+		release: control.Release{Version: "1.2.0-1"},
+		commit:  "abcdef",
+		modTime: time.Unix(1602792334, 0),
+	}
+
+	args, env, err := b.getBuildArgs(target{
+		pkg: control.Package{
+			LDFlags: "-X main.version={{.Version}} -X main.commit={{.Commit}} -X main.date={{.Date}}",
+			Tags:    []string{"netgo", "osusergo"},
+			Cgo:     &cgo,
+			Env:     map[string]string{"GOFLAGS": "-mod=vendor", "GOARM": "{{if eq .Arch \"arm\"}}7{{end}}"},
+		},
+		Target: control.Target{Os: "linux", Arch: "arm"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedArgs := []string{"build", "-trimpath",
+		"-ldflags=-buildid= -X main.version=1.2.0 -X main.commit=abcdef -X main.date=2020-10-15T20:05:34Z",
+		"-tags", "netgo,osusergo"}
+	if strings.Join(args, "|") != strings.Join(expectedArgs, "|") {
+		t.Errorf("wrong args (got: %v want: %v)", args, expectedArgs)
+	}
+
+	expectedEnv := []string{"CGO_ENABLED=0", "GOARM=7", "GOFLAGS=-mod=vendor"}
+	if strings.Join(env, "|") != strings.Join(expectedEnv, "|") {
+		t.Errorf("wrong env (got: %v want: %v)", env, expectedEnv)
+	}
+
+	if _, _, err := b.getBuildArgs(target{pkg: control.Package{LDFlags: "{{.Unknown}}"}}); err == nil {
+		t.Error("unknown template variable should fail")
+	}
+
+	// Profile & target settings are merged with the package ones
+	b.profile = &control.Profile{LDFlags: "-s -w", Tags: []string{"release"}, Env: map[string]string{"FOO": "profile"}}
+	args, env, err = b.getBuildArgs(target{
+		pkg: control.Package{
+			LDFlags: "-X main.version={{.Version}}",
+			Env:     map[string]string{"FOO": "package", "BAR": "package"},
+		},
+		Target: control.Target{Os: "linux", Arch: "arm", Variant: "6", Tags: []string{"arm"}, Env: map[string]string{"BAR": "target"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedArgs = []string{"build", "-trimpath", "-ldflags=-buildid= -X main.version=1.2.0 -s -w", "-tags", "release,arm"}
+	if strings.Join(args, "|") != strings.Join(expectedArgs, "|") {
+		t.Errorf("wrong args (got: %v want: %v)", args, expectedArgs)
+	}
+
+	expectedEnv = []string{"GOARM=6", "BAR=target", "FOO=profile"}
+	if strings.Join(env, "|") != strings.Join(expectedEnv, "|") {
+		t.Errorf("wrong env (got: %v want: %v)", env, expectedEnv)
+	}
+}
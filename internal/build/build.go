@@ -2,21 +2,90 @@ package build
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-pkg-org/gopkg/internal/config"
 	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/lint"
+	"github.com/go-pkg-org/gopkg/internal/patch"
 	"github.com/go-pkg-org/gopkg/internal/pkg"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v2"
 )
 
+// logsDir is the directory (inside the build directory) where the targets build logs are placed
+const logsDir = "logs"
+
+// Options are the options used to customize a build
+type Options struct {
+	// OutputDir is the directory where the packages are saved (default to the current directory)
+	OutputDir string
+	// PristineSource build the source package without applying the patches
+	PristineSource bool
+	// Jobs is the number of targets built concurrently (default to the number of CPUs)
+	Jobs int
+	// FailFast stop building the targets at the first failure
+	FailFast bool
+	// VerifyReproducible build the packages twice and make sure the results are identical
+	VerifyReproducible bool
+	// Profile is the name of the build profile to use (defined in metadata)
+	Profile string
+	// SkipTests disable the test stage
+	SkipTests bool
+	// NoCache disable the build cache (binary packages are always rebuilt)
+	NoCache bool
+	// Hermetic build in an isolated environment only containing the declared build dependencies
+	Hermetic bool
+}
+
+// builder hold the state of a control package build
+type builder struct {
+	// path to the control directory
+	path string
+	// directory where the packages are saved
+	outputDir string
+	// buildDir is the temporary directory where intermediate files are placed
+	buildDir string
+	// buildLog is the file where the build output is recorded
+	buildLog *os.File
+	conf     *config.Config
+	goPath   string
+	metadata control.Metadata
+	release  control.Release
+	profile  *control.Profile
+	opts     Options
+	// modTime is the time used for reproducible builds (SOURCE_DATE_EPOCH)
+	modTime time.Time
+	// commit is the upstream commit being built, if known
+	commit string
+	// hermetic is the isolated go environment, if hermetic mode is enabled
+	hermetic *hermeticEnv
+	// cache is the build cache, if enabled
+	cache *buildCache
+	// hooks are the hook scripts added to the binary packages, indexed by name
+	hooks map[string][]byte
+	// extraFiles are the extra files of the binary packages, indexed by package alias
+	extraFiles map[string][]extraFile
+	filesMutex sync.Mutex
+
+	mutex sync.Mutex
+	// the packages produced by the build
+	packages []string
+}
+
 // Build will build control package located as directory
-// and produce binary / dev packages into directory/build folder
-func Build(path string) error {
+// and produce binary / dev packages into the output directory
+// a build manifest listing the produced packages is written alongside them
+func Build(path string, opts Options) error {
 	// If path is pointing to a .pkg file, extract it
 	if strings.HasSuffix(path, "."+pkg.FileExt) {
 		log.Debug().Str("package", path).Msg("Extracting control package")
@@ -28,70 +97,326 @@ func Build(path string) error {
 		path = p
 	}
 
-	config, err := config.Default()
+	// Make sure the control directory is valid
+	issues, err := lint.Check(path)
 	if err != nil {
 		return err
 	}
+	if errors := lint.Errors(issues); len(errors) > 0 {
+		lint.Log(errors)
+		return fmt.Errorf("%d lint error(s) found, run `gopkg lint` for details", len(errors))
+	}
 
-	m, c, err := control.ReadCtrlDirectory(path)
-	if err != nil {
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		outputDir = "."
+	}
+	if err := os.MkdirAll(outputDir, 0750); err != nil {
 		return err
 	}
 
-	// Recreate build directory
-	if err := os.RemoveAll(filepath.Join(path, "build")); err != nil {
-		return err
+	var pkgs []string
+	if opts.VerifyReproducible {
+		pkgs, err = verifyReproducible(path, outputDir, opts)
+	} else {
+		pkgs, err = build(path, outputDir, opts)
 	}
-	if err := os.MkdirAll(filepath.Join(path, "build"), 0750); err != nil {
+	if err != nil {
 		return err
 	}
 
+	return writeManifest(outputDir, pkgs)
+}
+
+// build the control package located at path and save the packages into outputDir
+// intermediate files are placed in a temporary directory, kept if the build fails
+// this method returns the path of the produced packages
+func build(path, outputDir string, opts Options) (pkgs []string, err error) {
+	config, err := config.Default()
+	if err != nil {
+		return nil, err
+	}
+
+	m, c, err := control.ReadCtrlDirectory(path)
+	if err != nil {
+		return nil, err
+	}
+
 	goPath, err := config.GetGoPathDir()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	release, err := c.LatestRelease()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &builder{
+		path:      path,
+		outputDir: outputDir,
+		conf:      config,
+		goPath:    goPath,
+		metadata:  m,
+		release:   release,
+		opts:      opts,
+	}
+
+	// Record the build output in the build log
+	logName := fmt.Sprintf("%s_%s.build.log", pkg.GetName(m.ImportPath, false), b.release.Version)
+	if b.buildLog, err = os.Create(filepath.Join(outputDir, logName)); err != nil {
+		return nil, err
+	}
+	defer b.buildLog.Close()
+
+	logger := log.Logger
+	log.Logger = log.Logger.Output(zerolog.MultiLevelWriter(
+		zerolog.ConsoleWriter{Out: os.Stdout},
+		zerolog.ConsoleWriter{Out: b.buildLog, NoColor: true},
+	))
+	defer func() {
+		log.Logger = logger
+		if err != nil {
+			fmt.Fprintf(b.buildLog, "Build failed: %s\n", err)
+		}
+	}()
+
+	if opts.Profile != "" {
+		profile, exist := m.Profiles[opts.Profile]
+		if !exist {
+			return nil, fmt.Errorf("no such profile: %s", opts.Profile)
+		}
+		b.profile = &profile
+		log.Debug().Str("profile", opts.Profile).Msg("Using build profile")
 	}
 
-	// Get latest release
-	releaseVersion := c.Releases[len(c.Releases)-1].Version
+	if b.modTime, err = b.getSourceDateEpoch(); err != nil {
+		return nil, err
+	}
+
+	b.commit = getCommit(path)
+
+	// Create the build directory
+	if b.buildDir, err = ioutil.TempDir("", "gopkg-build-"); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			log.Warn().Str("dir", b.buildDir).Msg("Keeping build directory for inspection")
+			return
+		}
+		if err := os.RemoveAll(b.buildDir); err != nil {
+			log.Warn().Str("err", err.Error()).Msg("Error while removing build directory")
+		}
+	}()
+	if err := os.MkdirAll(filepath.Join(b.buildDir, logsDir), 0750); err != nil {
+		return nil, err
+	}
+
+	// Make sure the build dependencies are installed
+	if err := installBuildDependencies(config, path, outputDir, m.BuildDependencies); err != nil {
+		return nil, err
+	}
+
+	if opts.Hermetic {
+		if b.hermetic, err = newHermeticEnv(config, path, m.ImportPath, m.BuildDependencies); err != nil {
+			return nil, err
+		}
+		defer b.hermetic.cleanup()
+	}
 
 	log.Info().
 		Str("importPath", m.ImportPath).
-		Str("version", releaseVersion).
+		Str("version", b.release.Version).
 		Msgf("Building for control package")
 
-	// Run unit tests
-	cmd := exec.Command("go", "test", "./...")
-	cmd.Env = append(os.Environ(), fmt.Sprintf("GOPATH=%s", goPath))
-	cmd.Dir = path
-	output, err := cmd.Output()
-	if len(output) == 0 {
-		log.Error().Msg("No go packages found")
-		return nil
+	// Build pristine source package before patching
+	if opts.PristineSource {
+		if err := b.buildSourcePackage(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Test & build the packages using patched sources
+	if err := b.buildPatched(); err != nil {
+		return nil, err
 	}
 
-	fmt.Println(string(output))
+	// Finally build control package (using pristine sources)
+	if err := b.buildControlPackage(); err != nil {
+		return nil, err
+	}
 
+	return b.packages, nil
+}
+
+// buildPatched apply the patches on the control package
+// then run the tests and build the source & binary packages
+// the patches are reverted once done
+func (b *builder) buildPatched() (err error) {
+	// Apply the patches, and revert them once done
+	applied, err := patch.Apply(b.path)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		if revertErr := patch.Revert(b.path, applied); revertErr != nil && err == nil {
+			err = revertErr
+		}
+	}()
+
+	// Run unit tests
+	if err := b.runTests(); err != nil {
+		return err
+	}
+
+	// Build (patched) source package
+	if !b.opts.PristineSource {
+		if err := b.buildSourcePackage(); err != nil {
+			return err
+		}
+	}
+
+	return b.buildBinaryPackages()
+}
+
+// writeLog write given output in the build log and in given writer (if any)
+func (b *builder) writeLog(w io.Writer, output []byte) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if w != nil {
+		_, _ = w.Write(output)
+	}
+	_, _ = b.buildLog.Write(output)
+}
+
+// getGoEnv returns the environment used to run go
+// in hermetic mode the environment is scrubbed
+func (b *builder) getGoEnv() []string {
+	if b.hermetic != nil {
+		return append([]string{}, b.hermetic.env...)
+	}
+	return append(os.Environ(), fmt.Sprintf("GOPATH=%s", b.goPath))
+}
+
+// checkUndeclaredImports returns a clear error if given go output failed because
+// of imports missing from the build dependencies (hermetic mode only)
+func (b *builder) checkUndeclaredImports(output []byte, err error) error {
+	if b.hermetic == nil {
+		return err
+	}
+
+	if imports := getUndeclaredImports(output); len(imports) > 0 {
+		return fmt.Errorf("undeclared imports (add their source packages to build_dependencies): %s", strings.Join(imports, ", "))
+	}
+
+	return err
+}
+
+// getSourceDateEpoch returns the time used for reproducible builds
+// either from SOURCE_DATE_EPOCH environment variable, or from the release date
+func (b *builder) getSourceDateEpoch() (time.Time, error) {
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		sec, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH: %s", epoch)
+		}
+		return time.Unix(sec, 0), nil
+	}
+
+	if b.release.Date == "" {
+		log.Warn().Str("version", b.release.Version).Msg("Release has no date, using epoch")
+		return time.Unix(0, 0), nil
+	}
+
+	return b.release.Time()
+}
+
+// getCommit returns the upstream commit of the control directory at given path
+// an empty string is returned if the control directory is not a git repository
+func getCommit(path string) string {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+		return ""
+	}
+
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = path
+	b, err := cmd.Output()
+	if err != nil {
+		log.Warn().Str("err", err.Error()).Msg("Error while getting upstream commit")
+		return ""
+	}
 
-	// Build source package
-	if err := buildSourcePackage(path, m.ImportPath, releaseVersion); err != nil {
+	return strings.TrimSpace(string(b))
+}
+
+// writePackage save the package in output directory and register it
+func (b *builder) writePackage(fileName string, entries []pkg.Entry) error {
+	path := filepath.Join(b.outputDir, fileName)
+	if err := pkg.WriteAt(path, entries, true, b.modTime); err != nil {
 		return err
 	}
 
-	for _, p := range m.Packages {
-		for targetOs, targetArches := range p.Targets {
-			for _, targetArch := range targetArches {
-				if err = buildBinaryPackage(goPath, path, releaseVersion, targetOs, targetArch, p); err != nil {
-					return err
-				}
-			}
+	b.addPackage(path)
+	return nil
+}
+
+// createEntries returns the entries of the control directory files, without the build artifacts
+func (b *builder) createEntries(prefix string, excludedFiles []string) ([]pkg.Entry, error) {
+	entries, err := pkg.CreateEntries(b.path, prefix, excludedFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []pkg.Entry
+	for _, e := range entries {
+		if b.isArtifact(e.FilePath) {
+			log.Trace().Str("file", e.FilePath).Msg("Skipping build artifact")
+			continue
 		}
+		filtered = append(filtered, e)
 	}
 
-	// Finally build control package
-	return buildControlPackage(path, m.ImportPath, releaseVersion)
+	return filtered, nil
+}
+
+// isArtifact returns true if the file at given path was produced by a build (packages, build log, test report...)
+// such files end up in the control directory when building in it (the default output directory)
+func (b *builder) isArtifact(path string) bool {
+	// Output directory inside the control directory (f.e ./out)
+	if isWithin(b.path, b.outputDir) && !isWithin(b.outputDir, b.path) && isWithin(b.outputDir, path) {
+		return true
+	}
+
+	// Artifacts of the current or previous builds made in the control directory
+	if dir := filepath.Dir(path); !isWithin(dir, b.path) || !isWithin(b.path, dir) {
+		return false
+	}
+
+	return pkg.IsBuildArtifact(filepath.Base(path))
+}
+
+// isWithin returns true if path is dir or one of its descendants
+func isWithin(dir, path string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// addPackage register the package at given path as produced by the build
+func (b *builder) addPackage(path string) {
+	b.mutex.Lock()
+	b.packages = append(b.packages, path)
+	b.mutex.Unlock()
 }
 
 func extractControlPackage(path string) (string, error) {
@@ -129,94 +454,56 @@ func extractControlPackage(path string) (string, error) {
 	return strings.TrimSuffix(path, "."+pkg.FileExt), nil
 }
 
-func buildControlPackage(directory, importPath string, releaseVersion string) error {
-	fileName, err := pkg.GetFileName(importPath, releaseVersion, "", "", pkg.Control)
+func (b *builder) buildControlPackage() error {
+	fileName, err := pkg.GetFileName(b.metadata.ImportPath, b.release.Version, "", "", pkg.Control)
 	if err != nil {
 		return err
 	}
 
-	dir, err := pkg.CreateEntries(directory, strings.TrimSuffix(fileName, "."+pkg.FileExt), []string{".git"})
+	prefix := strings.TrimSuffix(fileName, "."+pkg.FileExt)
+	dir, err := b.createEntries(prefix, []string{".git", control.BuildInfoFile})
 	if err != nil {
 		return err
 	}
 
-	// Save the package in `./<fileName>`
-	if err := pkg.Write(fileName, dir, true); err != nil {
+	// Ship the build info in the control package
+	info, err := b.getBuildInfo(b.conf)
+	if err != nil {
 		return err
 	}
-
-	log.Info().Str("package", fileName).Msg("Successfully built control package")
-	return nil
-}
-
-func buildSourcePackage(directory, importPath, releaseVersion string) error {
-	fileName, err := pkg.GetFileName(importPath, releaseVersion, "", "", pkg.Source)
+	content, err := yaml.Marshal(info)
 	if err != nil {
 		return err
 	}
-
-	dir, err := pkg.CreateEntries(directory, importPath, []string{".git", control.GoPkgDir})
-	if err != nil {
+	infoPath := filepath.Join(b.buildDir, control.BuildInfoFile)
+	if err := ioutil.WriteFile(infoPath, content, 0640); err != nil {
 		return err
 	}
+	dir = append(dir, pkg.Entry{FilePath: infoPath, ArchivePath: filepath.Join(prefix, control.BuildInfoFile)})
 
-	// Save the package in `./<fileName>`
-	if err := pkg.Write(fileName, dir, true); err != nil {
+	if err := b.writePackage(fileName, dir); err != nil {
 		return err
 	}
 
-	log.Info().Str("package", fileName).Msg("Successfully built source package")
+	log.Info().Str("package", fileName).Msg("Successfully built control package")
 	return nil
 }
 
-func buildBinaryPackage(goPath, directory, releaseVersion, targetOs, targetArch string, p control.Package) error {
-	pkgName, err := pkg.GetFileName(p.Alias, releaseVersion, targetOs, targetArch, pkg.Binary)
+func (b *builder) buildSourcePackage() error {
+	fileName, err := pkg.GetFileName(b.metadata.ImportPath, b.release.Version, "", "", pkg.Source)
 	if err != nil {
 		return err
 	}
 
-	buildDir := filepath.Join(directory, "build", pkgName)
-
-	cmd := exec.Command("go", "build", "-o", filepath.Join(buildDir, p.BinName), p.Main)
-	log.Trace().Msgf("Executing `%s`", cmd.String())
-	cmd.Dir = directory
-	cmd.Stdout = ioutil.Discard
-	cmd.Stderr = os.Stderr
-	cmd.Env = append(os.Environ(), fmt.Sprintf("GOOS=%s", targetOs),
-		fmt.Sprintf("GOARCH=%s", targetArch), fmt.Sprintf("GOPATH=%s", goPath))
-	if err := cmd.Run(); err != nil {
-		return err
-	}
-
-	// Create the alias file
-	// this is used later on to determinate which package we are installing
-	if err := ioutil.WriteFile(filepath.Join(buildDir, "alias"), []byte(p.Alias), 0640); err != nil {
-		return err
-	}
-
-	// Save the package in `./<pkgName>`
-	err = pkg.Write(filepath.Join(pkgName), []pkg.Entry{
-		// Add the binary
-		{
-			FilePath:    filepath.Join(buildDir, p.BinName),
-			ArchivePath: filepath.Join("bin", p.BinName),
-		},
-		// Add the alias file
-		{
-			FilePath:    filepath.Join(buildDir, "alias"),
-			ArchivePath: "alias",
-		},
-	}, true)
-
+	dir, err := b.createEntries(b.metadata.ImportPath, []string{".git", control.GoPkgDir, control.BuildInfoFile})
 	if err != nil {
 		return err
 	}
 
-	// Remove the build file and keep package.
-	if err := os.RemoveAll(filepath.Join(buildDir)); err != nil {
+	if err := b.writePackage(fileName, dir); err != nil {
 		return err
 	}
 
-	log.Info().Str("package", pkgName).Msg("Successfully built binary package")
+	log.Info().Str("package", fileName).Msg("Successfully built source package")
 	return nil
 }
@@ -0,0 +1,154 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/patch"
+)
+
+func TestBuildReproducible(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dir := createControlDirectory(t, tmpDir)
+
+	// Every package (including the control package & its build info) should be identical
+	outputDir := filepath.Join(tmpDir, "out")
+	if err := Build(dir, Options{OutputDir: outputDir, SkipTests: true, NoCache: true, VerifyReproducible: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := ioutil.ReadFile(filepath.Join(outputDir, manifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Build(dir, Options{OutputDir: outputDir, SkipTests: true, NoCache: true}); err != nil {
+		t.Fatal(err)
+	}
+	second, err := ioutil.ReadFile(filepath.Join(outputDir, manifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("build manifests differ:\n%s\n%s", first, second)
+	}
+}
+
+func TestBuildInTreeThenNewPatch(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dir := createControlDirectory(t, tmpDir)
+
+	// Build in the control directory, as done by default
+	if err := Build(dir, Options{OutputDir: dir, SkipTests: true, NoCache: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, manifestFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The patch should only contain the source changes
+	if err := ioutil.WriteFile(filepath.Join(dir, "hello.go"), []byte("package main\n\nfunc main() { println(\"hello\") }\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := patch.New(dir, "hello.patch"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, control.GoPkgDir, patch.Dir, "hello.patch"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "hello.go") {
+		t.Errorf("patch is missing the source changes:\n%s", b)
+	}
+	for _, artifact := range []string{manifestFile, ".pkg", ".build.log", ".tests.json"} {
+		if strings.Contains(string(b), artifact) {
+			t.Errorf("patch contains build artifact %s:\n%s", artifact, b)
+		}
+	}
+
+	// The series can be applied on the pristine sources then reverted
+	if err := ioutil.WriteFile(filepath.Join(dir, "hello.go"), []byte(helloSource), 0640); err != nil {
+		t.Fatal(err)
+	}
+	applied, err := patch.Apply(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := patch.Revert(dir, applied); err != nil {
+		t.Error(err)
+	}
+}
+
+// helloSource is the upstream source of example.com/hello
+const helloSource = "package main\n\nfunc main() {}\n"
+
+// createControlDirectory creates the control directory of example.com/hello (a git repository) in given directory
+// an isolated configuration is used until the end of the test
+func createControlDirectory(t *testing.T, tmpDir string) string {
+	// Isolated configuration
+	confPath := filepath.Join(tmpDir, "config.yaml")
+	conf := "bin_dir: " + filepath.Join(tmpDir, "bin") + "\n" +
+		"cache_path: " + filepath.Join(tmpDir, "cache.json") + "\n" +
+		"src_dir: " + filepath.Join(tmpDir, "go", "src") + "\n" +
+		"build_cache_dir: " + filepath.Join(tmpDir, "build-cache") + "\n"
+	if err := ioutil.WriteFile(confPath, []byte(conf), 0640); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("GOPKG_CONFIG", confPath)
+	t.Cleanup(func() { os.Unsetenv("GOPKG_CONFIG") })
+
+	dir := filepath.Join(tmpDir, "hello")
+	files := map[string]string{
+		"go.mod":   "module example.com/hello\n\ngo 1.14\n",
+		"hello.go": helloSource,
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, args := range [][]string{{"init"}, {"add", "."}, {"commit", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("error while running git %v: %s", args, err)
+		}
+	}
+
+	m := control.Metadata{
+		ImportPath:  "example.com/hello",
+		Maintainers: []string{"Aloïs Micard <alois@micard.lu>"},
+		Packages: []control.Package{{
+			Alias:       "example.com/hello",
+			Main:        ".",
+			BinName:     "hello",
+			Description: "Say hello",
+			Targets:     map[string][]string{runtime.GOOS: {runtime.GOARCH}},
+		}},
+	}
+	if err := control.CreateCtrlDirectory(dir, "1.0.0", "Aloïs Micard <alois@micard.lu>", m); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
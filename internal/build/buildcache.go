@@ -0,0 +1,248 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/rs/zerolog/log"
+)
+
+// buildCache is a content-addressed cache of binary packages
+// packages are indexed by a key computed from the build inputs
+type buildCache struct {
+	// dir is the cache directory
+	dir string
+	// inputs are the build inputs shared by every target (sources, dependencies, go version...)
+	inputs string
+}
+
+// newBuildCache returns the build cache used by given builder
+// the shared inputs are computed from the (patched) sources
+func newBuildCache(b *builder) (*buildCache, error) {
+	// The build artifacts (f.e previous packages, build log) written in the control directory are not inputs
+	sourceHash, err := treeChecksum(b.path, []string{".git", control.BuildInfoFile}, b.isArtifact)
+	if err != nil {
+		return nil, err
+	}
+
+	deps, err := getDependenciesInfo(b.conf, b.metadata.BuildDependencies)
+	if err != nil {
+		return nil, err
+	}
+
+	goVersion, err := b.getGoVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	lines = append(lines, "source "+sourceHash, "go "+goVersion, "commit "+b.commit)
+	lines = append(lines, fmt.Sprintf("date %d", b.modTime.Unix()))
+	for _, dep := range deps {
+		lines = append(lines, fmt.Sprintf("dependency %s %s %s", dep.Name, dep.Version, dep.SHA256))
+	}
+
+	// The hermetic environment paths are random, only record the mode
+	if b.hermetic != nil {
+		lines = append(lines, "hermetic")
+	} else {
+		env := getRecordedEnv(b.getGoEnv())
+		for _, key := range sortedKeys(env) {
+			lines = append(lines, fmt.Sprintf("env %s=%s", key, env[key]))
+		}
+	}
+
+	return &buildCache{dir: b.conf.BuildCacheDir, inputs: strings.Join(lines, "\n")}, nil
+}
+
+// key returns the cache key of given target spec
+func (c *buildCache) key(spec ...string) string {
+	h := sha256.New()
+	fmt.Fprintln(h, c.inputs)
+	for _, s := range spec {
+		fmt.Fprintln(h, s)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// path returns the path of the cached package with given key
+func (c *buildCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".pkg")
+}
+
+// get copy the cached package with given key to given path
+// this method returns false if the package is not cached
+func (c *buildCache) get(key, path string) (bool, error) {
+	cachedPath := c.path(key)
+
+	b, err := ioutil.ReadFile(cachedPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return false, err
+	}
+
+	// Mark the entry as recently used
+	now := time.Now()
+	if err := os.Chtimes(cachedPath, now, now); err != nil {
+		log.Warn().Str("err", err.Error()).Msg("Error while updating build cache entry")
+	}
+
+	return true, nil
+}
+
+// put store the package at given path with given key
+func (c *buildCache) put(key, path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	cachedPath := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(cachedPath), 0750); err != nil {
+		return err
+	}
+
+	// Write then rename so concurrent builds never read partial entries
+	tmpFile, err := ioutil.TempFile(filepath.Dir(cachedPath), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmpFile.Write(b); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), cachedPath)
+}
+
+// PruneCache remove the build cache entries not used since given duration
+// every entry is removed if maxAge is zero
+func PruneCache(maxAge time.Duration) error {
+	conf, err := config.Default()
+	if err != nil {
+		return err
+	}
+
+	removed, size, err := pruneCache(conf.BuildCacheDir, maxAge)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Int("entries", removed).Int64("bytes", size).Msg("Successfully pruned build cache")
+	return nil
+}
+
+// pruneCache remove the entries of the cache directory not used since maxAge
+// this method returns the number of removed entries and their size
+func pruneCache(dir string, maxAge time.Duration) (int, int64, error) {
+	removed, size := 0, int64(0)
+	limit := time.Now().Add(-maxAge)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if info.IsDir() || (maxAge > 0 && info.ModTime().After(limit)) {
+			return nil
+		}
+
+		log.Trace().Str("file", path).Msg("Removing build cache entry")
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+
+		removed++
+		size += info.Size()
+		return nil
+	})
+
+	return removed, size, err
+}
+
+// treeChecksum returns the sha256 checksum of the files of given directory
+// (names relative to the directory, permissions & content)
+// the files with excluded names, or for which skip (if set) returns true, are ignored
+func treeChecksum(dir string, excluded []string, skip func(path string) bool) (string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		for _, e := range excluded {
+			if info.Name() == e {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		if info.Mode().IsRegular() && (skip == nil || !skip(path)) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(h, "%s\n%o\n%d\n", filepath.ToSlash(rel), info.Mode().Perm(), len(b))
+		h.Write(b)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,133 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuildCache(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	c := &buildCache{dir: filepath.Join(tmpDir, "cache"), inputs: "source abcd"}
+
+	key := c.key("github.com/creekorful/foo", "linux", "amd64")
+	if key != c.key("github.com/creekorful/foo", "linux", "amd64") {
+		t.Error("cache key should be deterministic")
+	}
+	if key == c.key("github.com/creekorful/foo", "linux", "arm64") {
+		t.Error("cache key should depend on the target")
+	}
+	if other := (&buildCache{inputs: "source efgh"}).key("github.com/creekorful/foo", "linux", "amd64"); key == other {
+		t.Error("cache key should depend on the inputs")
+	}
+
+	path := filepath.Join(tmpDir, "foo.pkg")
+	if hit, err := c.get(key, path); err != nil || hit {
+		t.Errorf("cache should be empty (hit: %t, err: %v)", hit, err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("package"), 0640); err != nil {
+		t.Error(err)
+	}
+	if err := c.put(key, path); err != nil {
+		t.Error(err)
+	}
+
+	copyPath := filepath.Join(tmpDir, "copy.pkg")
+	if hit, err := c.get(key, copyPath); err != nil || !hit {
+		t.Errorf("cache should contains the package (hit: %t, err: %v)", hit, err)
+	}
+	if b, err := ioutil.ReadFile(copyPath); err != nil || string(b) != "package" {
+		t.Errorf("wrong cached package (%s)", b)
+	}
+}
+
+func TestPruneCache(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	c := &buildCache{dir: tmpDir}
+	for i, age := range []time.Duration{time.Hour, 48 * time.Hour} {
+		key := c.key(string(rune('a' + i)))
+		if err := os.MkdirAll(filepath.Dir(c.path(key)), 0750); err != nil {
+			t.Error(err)
+		}
+		if err := ioutil.WriteFile(c.path(key), []byte("pkg"), 0640); err != nil {
+			t.Error(err)
+		}
+		modTime := time.Now().Add(-age)
+		if err := os.Chtimes(c.path(key), modTime, modTime); err != nil {
+			t.Error(err)
+		}
+	}
+
+	removed, size, err := pruneCache(tmpDir, 24*time.Hour)
+	if err != nil {
+		t.Error(err)
+	}
+	if removed != 1 || size != 3 {
+		t.Errorf("wrong pruned entries (removed: %d, size: %d)", removed, size)
+	}
+
+	if removed, _, err := pruneCache(tmpDir, 0); err != nil || removed != 1 {
+		t.Errorf("every entry should have been removed (removed: %d, err: %v)", removed, err)
+	}
+
+	if _, _, err := pruneCache(filepath.Join(tmpDir, "missing"), 0); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTreeChecksum(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".git"), 0750); err != nil {
+		t.Error(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main"), 0640); err != nil {
+		t.Error(err)
+	}
+
+	first, err := treeChecksum(tmpDir, []string{".git"}, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Excluded files does not change the checksum
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, ".git", "HEAD"), []byte("ref"), 0640); err != nil {
+		t.Error(err)
+	}
+	if second, err := treeChecksum(tmpDir, []string{".git"}, nil); err != nil || second != first {
+		t.Error("excluded files should not change the checksum")
+	}
+
+	// Neither do the build artifacts written in the control directory
+	b := &builder{path: tmpDir, outputDir: tmpDir}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "foo_1.0.0.build.log"), []byte("log"), 0640); err != nil {
+		t.Error(err)
+	}
+	if second, err := treeChecksum(tmpDir, []string{".git"}, b.isArtifact); err != nil || second != first {
+		t.Error("build artifacts should not change the checksum")
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package foo"), 0640); err != nil {
+		t.Error(err)
+	}
+	if third, err := treeChecksum(tmpDir, []string{".git"}, nil); err != nil || third == first {
+		t.Error("changed files should change the checksum")
+	}
+}
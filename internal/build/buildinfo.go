@@ -0,0 +1,153 @@
+package build
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-pkg-org/gopkg/internal/cache"
+	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/util"
+)
+
+// recordedEnvs are the go environment variables recorded in the build info
+var recordedEnvs = []string{"CGO_ENABLED", "GO111MODULE", "GOEXPERIMENT", "GOFLAGS", "GOPROXY", "GOSUMDB", "GOTOOLCHAIN"}
+
+// getBuildInfo returns the build info of the current build
+func (b *builder) getBuildInfo(conf *config.Config) (control.BuildInfo, error) {
+	goVersion, err := b.getGoVersion()
+	if err != nil {
+		return control.BuildInfo{}, err
+	}
+
+	deps, err := getDependenciesInfo(conf, b.metadata.BuildDependencies)
+	if err != nil {
+		return control.BuildInfo{}, err
+	}
+
+	pkgs, err := checksums(b.packages)
+	if err != nil {
+		return control.BuildInfo{}, err
+	}
+
+	info := control.BuildInfo{
+		ImportPath:   b.metadata.ImportPath,
+		Version:      b.release.Version,
+		Commit:       b.commit,
+		Profile:      b.opts.Profile,
+		Hermetic:     b.hermetic != nil,
+		GoVersion:    goVersion,
+		HostOs:       runtime.GOOS,
+		HostArch:     runtime.GOARCH,
+		Env:          getRecordedEnv(b.getGoEnv()),
+		Dependencies: deps,
+		Packages:     map[string]string{},
+		SourceDate:   b.modTime.UTC().Format(time.RFC3339),
+		// The build info is shipped in the control package, the wall clock would make it differ on every build
+		StartedAt:  b.modTime.UTC().Format(time.RFC3339),
+		FinishedAt: b.modTime.UTC().Format(time.RFC3339),
+	}
+
+	for name, sum := range pkgs {
+		info.Packages[name] = hex.EncodeToString(sum)
+	}
+
+	return info, nil
+}
+
+// getGoVersion returns the version of the go toolchain used to build (f.e go1.15.2)
+// `go version` is used since `go env GOVERSION` requires go 1.16
+func (b *builder) getGoVersion() (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("go", "version")
+	cmd.Env = b.getGoEnv()
+	cmd.Dir = b.path
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error while getting go version (%s: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseGoVersion(string(output))
+}
+
+// parseGoVersion returns the version from given `go version` output (f.e go version go1.15.2 linux/amd64)
+func parseGoVersion(output string) (string, error) {
+	fields := strings.Fields(output)
+	if len(fields) < 3 || fields[0] != "go" || fields[1] != "version" {
+		return "", fmt.Errorf("unexpected go version output: %s", strings.TrimSpace(output))
+	}
+
+	return fields[2], nil
+}
+
+// getRecordedEnv returns the recorded variables of given environment
+func getRecordedEnv(env []string) map[string]string {
+	recorded := map[string]string{}
+	for _, e := range env {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) == 2 && util.Contains(recordedEnvs, parts[0]) {
+			recorded[parts[0]] = parts[1]
+		}
+	}
+
+	return recorded
+}
+
+// getDependenciesInfo returns the installed version & checksum of given build dependencies
+func getDependenciesInfo(conf *config.Config, buildDeps []string) ([]control.BuildInfoDependency, error) {
+	c, err := cache.Read(conf.CachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []control.BuildInfoDependency
+	for _, dep := range buildDeps {
+		sum, err := filesChecksum(conf.SrcDir, c.GetFiles(dep))
+		if err != nil {
+			return nil, err
+		}
+
+		deps = append(deps, control.BuildInfoDependency{
+			Name:    dep,
+			Version: c.GetVersion(dep),
+			SHA256:  sum,
+		})
+	}
+
+	return deps, nil
+}
+
+// filesChecksum returns the sha256 checksum of given files (names relative to dir and content)
+func filesChecksum(dir string, files []string) (string, error) {
+	files = append([]string{}, files...)
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(h, "%s\n%d\n", filepath.ToSlash(rel), len(b))
+		h.Write(b)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,83 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestGetRecordedEnv(t *testing.T) {
+	env := getRecordedEnv([]string{"HOME=/root", "GOPROXY=off", "CGO_ENABLED=0", "GOPROXY=direct", "AWS_SECRET=foo"})
+
+	if len(env) != 2 || env["GOPROXY"] != "direct" || env["CGO_ENABLED"] != "0" {
+		t.Errorf("wrong recorded env (%v)", env)
+	}
+}
+
+func TestParseGoVersion(t *testing.T) {
+	if version, err := parseGoVersion("go version go1.15.2 linux/amd64\n"); err != nil || version != "go1.15.2" {
+		t.Errorf("wrong go version (%s, %v)", version, err)
+	}
+
+	if _, err := parseGoVersion("command not found"); err == nil {
+		t.Error("parseGoVersion should have failed")
+	}
+}
+
+func TestCreateEntries(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := []string{
+		"main.go",
+		"testdata/old.pkg",
+		"foo_1.0.0.build.log",
+		"foo_1.0.0.tests.json",
+		"foo_1.0.0_linux_amd64.pkg",
+		manifestFile,
+		"out/foo_1.0.0_linux_amd64.pkg",
+	}
+	for _, name := range files {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(name), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string][]string{
+		// Building in the control directory (default)
+		tmpDir: {"foo/main.go", "foo/out/foo_1.0.0_linux_amd64.pkg", "foo/testdata/old.pkg"},
+		// Building in a sub directory, the artifacts of previous builds in the control directory are ignored too
+		filepath.Join(tmpDir, "out"): {"foo/main.go", "foo/testdata/old.pkg"},
+		// Building outside of the control directory
+		os.TempDir(): {"foo/main.go", "foo/out/foo_1.0.0_linux_amd64.pkg", "foo/testdata/old.pkg"},
+	}
+
+	for outputDir, expected := range tests {
+		b := &builder{path: tmpDir, outputDir: outputDir}
+		entries, err := b.createEntries("foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, e := range entries {
+			names = append(names, filepath.ToSlash(e.ArchivePath))
+		}
+		sort.Strings(names)
+		sort.Strings(expected)
+
+		if strings.Join(names, " ") != strings.Join(expected, " ") {
+			t.Errorf("wrong entries for output %s (got: %v want: %v)", outputDir, names, expected)
+		}
+	}
+}
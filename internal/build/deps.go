@@ -0,0 +1,92 @@
+package build
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-pkg-org/gopkg/internal/cache"
+	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/install"
+	"github.com/go-pkg-org/gopkg/internal/pkg"
+	"github.com/rs/zerolog/log"
+)
+
+// installBuildDependencies install the missing build dependencies of the control directory at given path
+// the source packages are searched in the output directory, the control directory parent
+// and the configured repositories. An error listing the unresolvable dependencies is returned if any
+func installBuildDependencies(conf *config.Config, path, outputDir string, buildDeps []string) error {
+	c, err := cache.Read(conf.CachePath)
+	if err != nil {
+		return err
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	dirs := append([]string{outputDir, filepath.Dir(absPath)}, conf.Repositories...)
+
+	var unresolved []string
+	for _, dep := range buildDeps {
+		if c.GetFiles(dep) != nil {
+			log.Debug().Str("dependency", dep).Msg("Build dependency already installed")
+			continue
+		}
+
+		pkgPath, err := findSourcePackage(dirs, dep)
+		if err != nil {
+			return err
+		}
+		if pkgPath == "" {
+			unresolved = append(unresolved, dep)
+			continue
+		}
+
+		log.Info().Str("dependency", dep).Str("package", pkgPath).Msg("Installing build dependency")
+		if err := install.Install(pkgPath, install.Options{}); err != nil {
+			return fmt.Errorf("error while installing build dependency %s: %s", dep, err)
+		}
+	}
+
+	if len(unresolved) > 0 {
+		return fmt.Errorf("unresolvable build dependencies: %s", strings.Join(unresolved, ", "))
+	}
+
+	return nil
+}
+
+// findSourcePackage returns the path of the latest source package with given name found in given directories
+// an empty string is returned if no such package exist
+func findSourcePackage(dirs []string, name string) (string, error) {
+	bestPath, bestVersion := "", ""
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+
+		for _, file := range files {
+			if file.IsDir() || !strings.HasSuffix(file.Name(), "."+pkg.FileExt) {
+				continue
+			}
+
+			pkgName, pkgVersion, _, _, pkgType, err := pkg.ParseFileName(file.Name())
+			if err != nil || pkgType != pkg.Source || pkgName != name {
+				continue
+			}
+
+			if bestPath == "" || control.CompareVersions(pkgVersion, bestVersion) > 0 {
+				bestPath, bestVersion = filepath.Join(dir, file.Name()), pkgVersion
+			}
+		}
+	}
+
+	return bestPath, nil
+}
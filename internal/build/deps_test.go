@@ -0,0 +1,51 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindSourcePackage(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := []string{
+		"first/github.com-creekorful-foo-src_1.2.0-1.pkg",
+		"first/github.com-creekorful-foo_1.10.0-1.pkg",
+		"second/github.com-creekorful-foo-src_1.10.0-1.pkg",
+		"second/github.com-creekorful-foo-src_1.9.0-2.pkg",
+		"second/github.com-creekorful-bar-src_2.0.0-1.pkg",
+	}
+	for _, name := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Error(err)
+		}
+		if err := ioutil.WriteFile(path, []byte{}, 0640); err != nil {
+			t.Error(err)
+		}
+	}
+
+	dirs := []string{filepath.Join(tmpDir, "first"), filepath.Join(tmpDir, "second"), filepath.Join(tmpDir, "missing")}
+
+	path, err := findSourcePackage(dirs, "github.com-creekorful-foo-src")
+	if err != nil {
+		t.Error(err)
+	}
+	if path != filepath.Join(tmpDir, "second", "github.com-creekorful-foo-src_1.10.0-1.pkg") {
+		t.Errorf("wrong source package (%s)", path)
+	}
+
+	path, err = findSourcePackage(dirs, "github.com-creekorful-baz-src")
+	if err != nil {
+		t.Error(err)
+	}
+	if path != "" {
+		t.Errorf("source package should not have been found (%s)", path)
+	}
+}
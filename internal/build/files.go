@@ -0,0 +1,136 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/pkg"
+	"github.com/rs/zerolog/log"
+)
+
+// extraFile is an extra file of a binary package
+type extraFile struct {
+	archivePath string
+	content     []byte
+}
+
+// getExtraFiles returns the extra files of given package
+// generated files are computed once per package, using a binary built for the host
+func (b *builder) getExtraFiles(p control.Package) ([]extraFile, error) {
+	b.filesMutex.Lock()
+	defer b.filesMutex.Unlock()
+
+	if files, exist := b.extraFiles[p.Alias]; exist {
+		return files, nil
+	}
+
+	var files []extraFile
+	hostBinaries := map[string]string{}
+	for _, f := range p.Files {
+		if err := f.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid file for %s: %s", p.Alias, err)
+		}
+
+		binary, err := p.GetBinary(f.Binary)
+		if err != nil {
+			return nil, err
+		}
+
+		var content []byte
+		if f.Source != "" {
+			content, err = ioutil.ReadFile(filepath.Join(b.path, filepath.FromSlash(f.Source)))
+		} else {
+			hostBinary, exist := hostBinaries[binary.BinName]
+			if !exist {
+				if hostBinary, err = b.buildHostBinary(p, binary); err != nil {
+					return nil, err
+				}
+				hostBinaries[binary.BinName] = hostBinary
+			}
+			content, err = b.generateFile(hostBinary, f.Command)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error while creating %s file of %s: %s", f.Section, p.Alias, err)
+		}
+
+		files = append(files, extraFile{archivePath: f.ArchivePath(binary.BinName), content: content})
+	}
+
+	if b.extraFiles == nil {
+		b.extraFiles = map[string][]extraFile{}
+	}
+	b.extraFiles[p.Alias] = files
+
+	return files, nil
+}
+
+// buildHostBinary build the given binary of the package for the host & returns its path
+func (b *builder) buildHostBinary(p control.Package, binary control.Binary) (string, error) {
+	t := target{pkg: p, Target: control.Target{Os: runtime.GOOS, Arch: runtime.GOARCH}}
+
+	args, env, err := b.getBuildArgs(t)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(b.buildDir, "host", pkg.GetName(p.Alias, false), binary.BinName)
+	args = append(args, "-o", path, binary.Main)
+
+	cmd := exec.Command("go", args...)
+	log.Trace().Msgf("Executing `%s`", cmd.String())
+	cmd.Dir = b.path
+	cmd.Env = append(b.getGoEnv(), fmt.Sprintf("GOOS=%s", t.Os), fmt.Sprintf("GOARCH=%s", t.Arch))
+	cmd.Env = append(cmd.Env, env...)
+
+	output, err := cmd.CombinedOutput()
+	if len(output) > 0 {
+		b.writeLog(nil, append([]byte(fmt.Sprintf("==> %s (host) %s\n", p.Alias, binary.BinName)), output...))
+	}
+	if err != nil {
+		return "", fmt.Errorf("error while building host binary of %s: %s", p.Alias, b.checkUndeclaredImports(output, err))
+	}
+
+	return path, nil
+}
+
+// generateFile run the host binary with given arguments & returns its standard output
+// the binary is run from an empty directory with a minimal environment so the output is reproducible
+func (b *builder) generateFile(binary string, args []string) ([]byte, error) {
+	dir, err := ioutil.TempDir(b.buildDir, "generate-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(binary, args...)
+	log.Trace().Msgf("Executing `%s`", cmd.String())
+	cmd.Dir = dir
+	cmd.Env = []string{fmt.Sprintf("HOME=%s", dir), fmt.Sprintf("PATH=%s", os.Getenv("PATH"))}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("`%s %s` failed: %s (%s)", filepath.Base(binary), strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// filesSpec returns the extra files definition of given package in a textual form (used as build cache input)
+// the generated content only depends on the sources, which are already part of the cache inputs
+func filesSpec(p control.Package) string {
+	var sb strings.Builder
+	for _, f := range p.Files {
+		fmt.Fprintf(&sb, "file %s %s %s %s %q\n", f.Section, f.Source, f.Name, f.Binary, f.Command)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,45 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+)
+
+func TestGetExtraFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "docs"), 0750); err != nil {
+		t.Error(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "docs", "foo.1"), []byte(".TH FOO 1"), 0640); err != nil {
+		t.Error(err)
+	}
+
+	b := &builder{path: tmpDir}
+	p := control.Package{Alias: "foo", BinName: "foo", Files: []control.File{
+		{Section: "man", Source: "docs/foo.1"},
+		{Section: "etc", Source: "docs/foo.1", Name: "foo.conf"},
+	}}
+
+	files, err := b.getExtraFiles(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].archivePath != "share/man/man1/foo.1" || string(files[0].content) != ".TH FOO 1" ||
+		files[1].archivePath != "etc/foo/foo.conf" {
+		t.Errorf("wrong extra files (%+v)", files)
+	}
+
+	p.Files = append(p.Files, control.File{Section: "doc", Source: "README.md"})
+	if _, err := b.getExtraFiles(control.Package{Alias: "bar", BinName: "bar", Files: p.Files}); err == nil {
+		t.Error("getExtraFiles should have failed")
+	}
+}
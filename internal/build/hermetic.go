@@ -0,0 +1,227 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-pkg-org/gopkg/internal/cache"
+	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/go-pkg-org/gopkg/internal/pkg"
+	"github.com/go-pkg-org/gopkg/internal/util"
+	"github.com/rs/zerolog/log"
+)
+
+// keptEnvs are the environment variables kept in hermetic mode
+var keptEnvs = []string{"PATH", "TMPDIR", "SYSTEMROOT"}
+
+// undeclaredImportRegex match the go error raised when an import cannot be resolved without network
+var undeclaredImportRegex = regexp.MustCompile(`package (\S+?)[:;].*module lookup disabled by GOPROXY=off`)
+
+// hermeticEnv is an isolated go environment only containing the declared build dependencies
+type hermeticEnv struct {
+	// dir is the temporary directory holding the environment
+	dir string
+	// env are the environment variables used to run go
+	env []string
+}
+
+// newHermeticEnv create an isolated go environment for the control directory at given path
+// the declared build dependencies are copied from the installed source packages
+func newHermeticEnv(conf *config.Config, path, importPath string, buildDeps []string) (*hermeticEnv, error) {
+	modFile := filepath.Join(path, "go.mod")
+	if _, err := os.Stat(modFile); err != nil {
+		return nil, fmt.Errorf("hermetic builds require a go.mod file")
+	}
+
+	c, err := cache.Read(conf.CachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := ioutil.TempDir("", "gopkg-hermetic-")
+	if err != nil {
+		return nil, err
+	}
+	h := &hermeticEnv{dir: dir}
+
+	goPath := filepath.Join(dir, "gopath")
+	replaces := map[string]string{}
+	for _, dep := range buildDeps {
+		files := c.GetFiles(dep)
+		if files == nil {
+			h.cleanup()
+			return nil, fmt.Errorf("build dependency %s is not installed", dep)
+		}
+
+		depImportPath, err := getImportPath(conf.SrcDir, dep, files)
+		if err != nil {
+			h.cleanup()
+			return nil, err
+		}
+
+		depDir := filepath.Join(goPath, "src", filepath.FromSlash(depImportPath))
+		if err := copyDependency(conf.SrcDir, depDir, depImportPath, files); err != nil {
+			h.cleanup()
+			return nil, err
+		}
+
+		log.Debug().Str("dependency", depImportPath).Msg("Added build dependency to hermetic environment")
+		replaces[depImportPath] = depDir
+	}
+
+	// Use a dedicated go.mod file so only the declared dependencies can be resolved
+	content, err := getModFile(modFile, importPath, replaces)
+	if err != nil {
+		h.cleanup()
+		return nil, err
+	}
+	tmpModFile := filepath.Join(dir, "go.mod")
+	if err := ioutil.WriteFile(tmpModFile, content, 0640); err != nil {
+		h.cleanup()
+		return nil, err
+	}
+
+	for _, key := range keptEnvs {
+		if value, exist := os.LookupEnv(key); exist {
+			h.env = append(h.env, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	h.env = append(h.env,
+		fmt.Sprintf("HOME=%s", filepath.Join(dir, "home")),
+		fmt.Sprintf("GOPATH=%s", goPath),
+		fmt.Sprintf("GOMODCACHE=%s", filepath.Join(goPath, "pkg", "mod")),
+		fmt.Sprintf("GOCACHE=%s", filepath.Join(dir, "cache")),
+		fmt.Sprintf("GOFLAGS=-mod=mod -modcacherw -modfile=%s", tmpModFile),
+		"GOPROXY=off",
+		"GOSUMDB=off",
+		"GOTOOLCHAIN=local",
+		"GO111MODULE=on",
+	)
+
+	return h, nil
+}
+
+// cleanup remove the hermetic environment
+func (h *hermeticEnv) cleanup() {
+	if err := os.RemoveAll(h.dir); err != nil {
+		log.Warn().Str("err", err.Error()).Msg("Error while removing hermetic environment")
+	}
+}
+
+// getImportPath returns the import path of the installed source package name
+// by looking at the package files (installed under srcDir)
+func getImportPath(srcDir, name string, files []string) (string, error) {
+	if len(files) > 0 {
+		rel, err := filepath.Rel(srcDir, files[0])
+		if err != nil {
+			return "", err
+		}
+
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		for i := 1; i < len(parts); i++ {
+			importPath := strings.Join(parts[:i], "/")
+			if pkg.GetName(importPath, true) == name {
+				return importPath, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("unable to find import path of %s", name)
+}
+
+// copyDependency copy the files of the dependency installed under srcDir into dir
+// a go.mod is created if the dependency does not provide one
+func copyDependency(srcDir, dir, importPath string, files []string) error {
+	prefix := filepath.Join(srcDir, filepath.FromSlash(importPath)) + string(filepath.Separator)
+	for _, file := range files {
+		if !strings.HasPrefix(file, prefix) {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, strings.TrimPrefix(file, prefix))
+		if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(target, b, 0640); err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); os.IsNotExist(err) {
+		return ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(fmt.Sprintf("module %s\n", importPath)), 0640)
+	}
+
+	return nil
+}
+
+// getModFile returns the go.mod used in hermetic mode
+// module & go directives are kept from the upstream go.mod, and the requirements
+// are replaced by the dependencies (import path -> directory)
+func getModFile(modFile, importPath string, replaces map[string]string) ([]byte, error) {
+	f, err := os.Open(modFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	module, goVersion := importPath, ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "module":
+			module = strings.Trim(fields[1], `"`)
+		case "go":
+			goVersion = fields[1]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var deps []string
+	for dep := range replaces {
+		deps = append(deps, dep)
+	}
+	sort.Strings(deps)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "module %s\n", module)
+	if goVersion != "" {
+		fmt.Fprintf(&buf, "\ngo %s\n", goVersion)
+	}
+	for _, dep := range deps {
+		fmt.Fprintf(&buf, "\nrequire %s v0.0.0\n", dep)
+		fmt.Fprintf(&buf, "replace %s => %s\n", dep, replaces[dep])
+	}
+
+	return buf.Bytes(), nil
+}
+
+// getUndeclaredImports returns the imports that could not be resolved in hermetic mode
+func getUndeclaredImports(output []byte) []string {
+	var imports []string
+	for _, match := range undeclaredImportRegex.FindAllSubmatch(output, -1) {
+		if importPath := string(match[1]); !util.Contains(imports, importPath) {
+			imports = append(imports, importPath)
+		}
+	}
+
+	return imports
+}
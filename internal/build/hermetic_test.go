@@ -0,0 +1,98 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetImportPath(t *testing.T) {
+	srcDir := filepath.Join("home", ".gopkg", "src")
+	files := []string{filepath.Join(srcDir, "github.com", "creekorful", "foo", "internal", "foo.go")}
+
+	importPath, err := getImportPath(srcDir, "github.com-creekorful-foo-src", files)
+	if err != nil {
+		t.Error(err)
+	}
+	if importPath != "github.com/creekorful/foo" {
+		t.Errorf("wrong import path (%s)", importPath)
+	}
+
+	if _, err := getImportPath(srcDir, "github.com-creekorful-bar-src", files); err == nil {
+		t.Error("getImportPath should have failed")
+	}
+}
+
+func TestCopyDependency(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcDir := filepath.Join(tmpDir, "src")
+	file := filepath.Join(srcDir, "github.com", "creekorful", "foo", "foo.go")
+	if err := os.MkdirAll(filepath.Dir(file), 0750); err != nil {
+		t.Error(err)
+	}
+	if err := ioutil.WriteFile(file, []byte("package foo"), 0640); err != nil {
+		t.Error(err)
+	}
+
+	dir := filepath.Join(tmpDir, "dep")
+	if err := copyDependency(srcDir, dir, "github.com/creekorful/foo", []string{file}); err != nil {
+		t.Error(err)
+	}
+
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "foo.go")); err != nil || string(b) != "package foo" {
+		t.Errorf("wrong copied file (%s)", b)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "go.mod")); err != nil || string(b) != "module github.com/creekorful/foo\n" {
+		t.Errorf("wrong generated go.mod (%s)", b)
+	}
+}
+
+func TestGetModFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	modFile := filepath.Join(tmpDir, "go.mod")
+	content := "module github.com/creekorful/foo\n\ngo 1.14\n\nrequire github.com/rs/zerolog v1.20.0\n"
+	if err := ioutil.WriteFile(modFile, []byte(content), 0640); err != nil {
+		t.Error(err)
+	}
+
+	b, err := getModFile(modFile, "github.com/creekorful/bar", map[string]string{
+		"github.com/rs/zerolog": "/tmp/zerolog",
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := "module github.com/creekorful/foo\n\ngo 1.14\n\nrequire github.com/rs/zerolog v0.0.0\nreplace github.com/rs/zerolog => /tmp/zerolog\n"
+	if string(b) != expected {
+		t.Errorf("wrong go.mod (%s)", b)
+	}
+}
+
+func TestGetUndeclaredImports(t *testing.T) {
+	output := `go: finding module for package github.com/baz/qux
+# example.com/p
+main.go:6:1: cannot find module providing package github.com/baz/qux: module lookup disabled by GOPROXY=off
+main.go:7:1: no required module provides package github.com/baz/quux; to add it: module lookup disabled by GOPROXY=off
+main.go:8:1: cannot find module providing package github.com/baz/qux: module lookup disabled by GOPROXY=off
+FAIL	example.com/p [setup failed]`
+
+	imports := getUndeclaredImports([]byte(output))
+	if len(imports) != 2 || imports[0] != "github.com/baz/qux" || imports[1] != "github.com/baz/quux" {
+		t.Errorf("wrong undeclared imports (%v)", imports)
+	}
+
+	if imports := getUndeclaredImports([]byte("main.go:6:1: undefined: foo")); len(imports) != 0 {
+		t.Errorf("wrong undeclared imports (%v)", imports)
+	}
+}
@@ -0,0 +1,96 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-pkg-org/gopkg/internal/pkg"
+	"github.com/rs/zerolog/log"
+)
+
+// manifestFile is the file (inside the output directory) listing the produced packages
+const manifestFile = pkg.ManifestFile
+
+// manifest list the packages produced by a build
+type manifest struct {
+	Packages []manifestEntry `json:"packages"`
+}
+
+// manifestEntry describe a produced package
+type manifestEntry struct {
+	File    string   `json:"file"`
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+	Type    pkg.Type `json:"type"`
+	Os      string   `json:"os,omitempty"`
+	Arch    string   `json:"arch,omitempty"`
+	Size    int64    `json:"size"`
+	SHA256  string   `json:"sha256"`
+}
+
+// writeManifest write the build manifest of given packages into outputDir
+func writeManifest(outputDir string, pkgs []string) error {
+	m, err := getManifest(pkgs)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(outputDir, manifestFile)
+	if err := ioutil.WriteFile(path, b, 0640); err != nil {
+		return err
+	}
+
+	log.Info().Str("manifest", path).Int("packages", len(m.Packages)).Msg("Successfully written build manifest")
+	return nil
+}
+
+// getManifest returns the manifest of given packages, sorted by file name
+func getManifest(pkgs []string) (manifest, error) {
+	m := manifest{Packages: []manifestEntry{}}
+
+	for _, p := range pkgs {
+		fileName := filepath.Base(p)
+		name, version, pkgOs, pkgArch, pkgType, err := pkg.ParseFileName(fileName)
+		if err != nil {
+			return manifest{}, err
+		}
+
+		info, err := os.Stat(p)
+		if err != nil {
+			return manifest{}, err
+		}
+
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return manifest{}, err
+		}
+		sum := sha256.Sum256(b)
+
+		m.Packages = append(m.Packages, manifestEntry{
+			File:    fileName,
+			Name:    name,
+			Version: version,
+			Type:    pkgType,
+			Os:      pkgOs,
+			Arch:    pkgArch,
+			Size:    info.Size(),
+			SHA256:  hex.EncodeToString(sum[:]),
+		})
+	}
+
+	sort.Slice(m.Packages, func(i, j int) bool {
+		return m.Packages[i].File < m.Packages[j].File
+	})
+
+	return m, nil
+}
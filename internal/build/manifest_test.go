@@ -0,0 +1,54 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-pkg-org/gopkg/internal/pkg"
+)
+
+func TestGetManifest(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var pkgs []string
+	for _, name := range []string{"github.com-creekorful-foo_1.0.0-1_linux_amd64.pkg", "github.com-creekorful-foo-src_1.0.0-1.pkg"} {
+		path := filepath.Join(tmpDir, name)
+		if err := ioutil.WriteFile(path, []byte("hello"), 0640); err != nil {
+			t.Error(err)
+		}
+		pkgs = append(pkgs, path)
+	}
+
+	m, err := getManifest(pkgs)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(m.Packages) != 2 {
+		t.Fatalf("wrong number of packages (%d)", len(m.Packages))
+	}
+
+	src := m.Packages[0]
+	if src.File != "github.com-creekorful-foo-src_1.0.0-1.pkg" || src.Type != pkg.Source || src.Os != "" {
+		t.Errorf("wrong source package entry (%+v)", src)
+	}
+
+	bin := m.Packages[1]
+	if bin.Name != "github.com-creekorful-foo" || bin.Version != "1.0.0-1" || bin.Type != pkg.Binary ||
+		bin.Os != "linux" || bin.Arch != "amd64" || bin.Size != 5 {
+		t.Errorf("wrong binary package entry (%+v)", bin)
+	}
+	if bin.SHA256 != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("wrong checksum (%s)", bin.SHA256)
+	}
+
+	if _, err := getManifest([]string{filepath.Join(tmpDir, "invalid.pkg")}); err == nil {
+		t.Error("getManifest should have failed")
+	}
+}
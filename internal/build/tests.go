@@ -0,0 +1,278 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-pkg-org/gopkg/internal/pkg"
+	"github.com/rs/zerolog/log"
+)
+
+// testEvent is an event emitted by go test -json
+type testEvent struct {
+	Action string
+	// Package is the tested package
+	Package string
+	// ImportPath is set instead of Package for build output
+	ImportPath string
+	Test       string
+	Output     string
+	Elapsed    float64
+}
+
+// testReport is the summary of the test stage
+type testReport struct {
+	Passed   int                 `json:"passed"`
+	Failed   int                 `json:"failed"`
+	Skipped  int                 `json:"skipped"`
+	Packages []packageTestResult `json:"packages"`
+}
+
+// packageTestResult is the test result of a package
+type packageTestResult struct {
+	Package string `json:"package"`
+	// Status is either pass, fail or skip (no test files)
+	Status      string   `json:"status"`
+	Passed      int      `json:"passed"`
+	Failed      int      `json:"failed"`
+	Skipped     int      `json:"skipped"`
+	Elapsed     float64  `json:"elapsed"`
+	FailedTests []string `json:"failed_tests,omitempty"`
+	// output is the package output, only kept for failed packages
+	output string
+}
+
+// statusMessages are the messages logged for each package test status
+var statusMessages = map[string]string{
+	"pass": "Tests passed",
+	"fail": "Tests failed",
+	"skip": "No test files",
+}
+
+// runTests run the upstream tests (excluding the configured packages)
+// and save the test report in the output directory
+func (b *builder) runTests() error {
+	if b.opts.SkipTests || b.metadata.Tests.Skip {
+		log.Warn().Msg("Skipping tests")
+		return nil
+	}
+
+	pkgs, err := b.getTestedPackages()
+	if err != nil {
+		return err
+	}
+	if len(pkgs) == 0 {
+		return fmt.Errorf("no go packages found")
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := append(append([]string{"test", "-json"}, b.metadata.Tests.Flags...), pkgs...)
+	cmd := exec.Command("go", args...)
+	cmd.Env = b.getGoEnv()
+	cmd.Dir = b.path
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	log.Trace().Msgf("Executing `%s`", cmd.String())
+	runErr := cmd.Run()
+
+	report, output, err := parseTestOutput(&stdout)
+	if err != nil {
+		return err
+	}
+
+	if err := b.writeTestReport(report); err != nil {
+		return err
+	}
+
+	for _, result := range report.Packages {
+		event := log.Info()
+		if result.Status == "fail" {
+			event = log.Error()
+			b.writeLog(os.Stderr, []byte(result.output))
+		}
+		event.Str("package", result.Package).
+			Int("passed", result.Passed).
+			Int("failed", result.Failed).
+			Int("skipped", result.Skipped).
+			Msg(statusMessages[result.Status])
+	}
+
+	if runErr != nil {
+		if stderr.Len() > 0 {
+			b.writeLog(os.Stderr, stderr.Bytes())
+		}
+
+		var failures []string
+		for _, result := range report.Packages {
+			if result.Status == "fail" {
+				failures = append(failures, result.Package)
+			}
+		}
+		if len(failures) > 0 {
+			runErr = fmt.Errorf("tests failed: %s", strings.Join(failures, ", "))
+		}
+
+		return b.checkUndeclaredImports(append(output, stderr.Bytes()...), runErr)
+	}
+
+	log.Info().
+		Int("passed", report.Passed).
+		Int("failed", report.Failed).
+		Int("skipped", report.Skipped).
+		Msg("All tests passed")
+
+	return nil
+}
+
+// getTestedPackages returns the packages to test, without the excluded ones
+func (b *builder) getTestedPackages() ([]string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("go", "list", "./...")
+	cmd.Env = b.getGoEnv()
+	cmd.Dir = b.path
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, b.checkUndeclaredImports(stderr.Bytes(), fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String())))
+	}
+
+	var pkgs []string
+	for _, p := range parseLines(output) {
+		if !isExcluded(p, b.metadata.Tests.Exclude) {
+			pkgs = append(pkgs, p)
+		}
+	}
+
+	return pkgs, nil
+}
+
+// isExcluded returns true if given package match one of the exclusion patterns
+// a pattern is either an import path or an import path followed by /...
+func isExcluded(importPath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if prefix := strings.TrimSuffix(pattern, "/..."); prefix != pattern {
+			if importPath == prefix || strings.HasPrefix(importPath, prefix+"/") {
+				return true
+			}
+		} else if importPath == pattern {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseTestOutput parse the go test -json output into a report
+// this method also returns the raw output of the tests
+func parseTestOutput(r io.Reader) (testReport, []byte, error) {
+	results := map[string]*packageTestResult{}
+	var output bytes.Buffer
+
+	getResult := func(name string) *packageTestResult {
+		if _, exist := results[name]; !exist {
+			results[name] = &packageTestResult{Package: name}
+		}
+		return results[name]
+	}
+
+	decoder := json.NewDecoder(r)
+	for {
+		var event testEvent
+		if err := decoder.Decode(&event); err == io.EOF {
+			break
+		} else if err != nil {
+			return testReport{}, nil, fmt.Errorf("invalid go test output: %s", err)
+		}
+
+		name := event.Package
+		if name == "" {
+			name = event.ImportPath
+		}
+		if name == "" {
+			continue
+		}
+
+		switch event.Action {
+		case "output", "build-output":
+			output.WriteString(event.Output)
+			getResult(name).output += event.Output
+		case "pass", "fail", "skip":
+			result := getResult(name)
+			if event.Test == "" {
+				result.Status = event.Action
+				result.Elapsed = event.Elapsed
+				continue
+			}
+
+			switch event.Action {
+			case "pass":
+				result.Passed++
+			case "fail":
+				result.Failed++
+				result.FailedTests = append(result.FailedTests, event.Test)
+			case "skip":
+				result.Skipped++
+			}
+		}
+	}
+
+	var report testReport
+	for _, result := range results {
+		// Ignore build only entries (merged with the package)
+		if result.Status == "" {
+			continue
+		}
+
+		if result.Status != "fail" {
+			result.output = ""
+		}
+
+		report.Passed += result.Passed
+		report.Failed += result.Failed
+		report.Skipped += result.Skipped
+		report.Packages = append(report.Packages, *result)
+	}
+
+	sort.Slice(report.Packages, func(i, j int) bool {
+		return report.Packages[i].Package < report.Packages[j].Package
+	})
+
+	return report, output.Bytes(), nil
+}
+
+// writeTestReport save the test report (json) in the output directory
+func (b *builder) writeTestReport(report testReport) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("%s_%s.tests.json", pkg.GetName(b.metadata.ImportPath, false), b.release.Version)
+	path := filepath.Join(b.outputDir, fileName)
+	if err := ioutil.WriteFile(path, content, 0640); err != nil {
+		return err
+	}
+
+	log.Debug().Str("report", path).Msg("Saved test report")
+	return nil
+}
+
+func parseLines(b []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
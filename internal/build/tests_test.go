@@ -0,0 +1,90 @@
+package build
+
+import (
+	"strings"
+	"testing"
+)
+
+const testOutput = `{"Action":"start","Package":"example.com/hello"}
+{"Action":"run","Package":"example.com/hello","Test":"TestHello"}
+{"Action":"output","Package":"example.com/hello","Test":"TestHello","Output":"--- PASS: TestHello (0.00s)\n"}
+{"Action":"pass","Package":"example.com/hello","Test":"TestHello","Elapsed":0}
+{"Action":"output","Package":"example.com/hello","Test":"TestSlow","Output":"--- SKIP: TestSlow (0.00s)\n"}
+{"Action":"skip","Package":"example.com/hello","Test":"TestSlow","Elapsed":0}
+{"Action":"pass","Package":"example.com/hello","Elapsed":0.006}
+{"Action":"output","Package":"example.com/hello/cmd/hello","Output":"?   \texample.com/hello/cmd/hello\t[no test files]\n"}
+{"Action":"skip","Package":"example.com/hello/cmd/hello","Elapsed":0}
+{"ImportPath":"example.com/hello/bye","Action":"build-output","Output":"bye.go:3:1: undefined: foo\n"}
+{"ImportPath":"example.com/hello/bye","Action":"build-fail"}
+{"Action":"output","Package":"example.com/hello/bye","Output":"FAIL\texample.com/hello/bye [build failed]\n"}
+{"Action":"fail","Package":"example.com/hello/bye","Elapsed":0}
+{"Action":"output","Package":"example.com/hello/world","Test":"TestWorld","Output":"--- FAIL: TestWorld (0.00s)\n"}
+{"Action":"fail","Package":"example.com/hello/world","Test":"TestWorld","Elapsed":0}
+{"Action":"fail","Package":"example.com/hello/world","Elapsed":0.002}
+`
+
+func TestParseTestOutput(t *testing.T) {
+	report, output, err := parseTestOutput(strings.NewReader(testOutput))
+	if err != nil {
+		t.Error(err)
+	}
+
+	if report.Passed != 1 || report.Failed != 1 || report.Skipped != 1 {
+		t.Errorf("wrong report summary (%+v)", report)
+	}
+
+	if len(report.Packages) != 4 {
+		t.Fatalf("wrong number of packages (%d)", len(report.Packages))
+	}
+
+	expected := []struct {
+		name   string
+		status string
+	}{
+		{"example.com/hello", "pass"},
+		{"example.com/hello/bye", "fail"},
+		{"example.com/hello/cmd/hello", "skip"},
+		{"example.com/hello/world", "fail"},
+	}
+	for i, e := range expected {
+		if report.Packages[i].Package != e.name || report.Packages[i].Status != e.status {
+			t.Errorf("wrong package result (%+v)", report.Packages[i])
+		}
+	}
+
+	if !strings.Contains(report.Packages[1].output, "undefined: foo") {
+		t.Errorf("missing build output (%s)", report.Packages[1].output)
+	}
+	if report.Packages[0].output != "" {
+		t.Errorf("output of passed package should not be kept (%s)", report.Packages[0].output)
+	}
+	if len(report.Packages[3].FailedTests) != 1 || report.Packages[3].FailedTests[0] != "TestWorld" {
+		t.Errorf("wrong failed tests (%v)", report.Packages[3].FailedTests)
+	}
+	if !strings.Contains(string(output), "--- FAIL: TestWorld") {
+		t.Errorf("wrong raw output (%s)", output)
+	}
+
+	if _, _, err := parseTestOutput(strings.NewReader("FAIL example.com/hello")); err == nil {
+		t.Error("parseTestOutput should have failed")
+	}
+}
+
+func TestIsExcluded(t *testing.T) {
+	patterns := []string{"example.com/hello/integration", "example.com/hello/e2e/..."}
+
+	tests := map[string]bool{
+		"example.com/hello":                 false,
+		"example.com/hello/integration":     true,
+		"example.com/hello/integration/foo": false,
+		"example.com/hello/e2e":             true,
+		"example.com/hello/e2e/foo":         true,
+		"example.com/hello/e2eutil":         false,
+	}
+
+	for importPath, expected := range tests {
+		if isExcluded(importPath, patterns) != expected {
+			t.Errorf("wrong exclusion for %s (want: %t)", importPath, expected)
+		}
+	}
+}
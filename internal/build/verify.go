@@ -0,0 +1,132 @@
+package build
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// verifyReproducible build the control package located at path twice
+// and make sure the produced packages are identical
+// if so, the files of the first build are moved into outputDir
+// this method returns the path of the produced packages
+func verifyReproducible(path, outputDir string, opts Options) ([]string, error) {
+	// Use temporary directories inside the output directory so the packages can be moved without crossing filesystems,
+	// or next to the control directory when the output directory is inside it, so they are not packaged by the builds
+	parentDir := outputDir
+	if isWithin(path, outputDir) {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		parentDir = filepath.Dir(absPath)
+	}
+
+	firstDir, err := ioutil.TempDir(parentDir, ".gopkg-verify-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(firstDir)
+
+	secondDir, err := ioutil.TempDir(parentDir, ".gopkg-verify-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(secondDir)
+
+	log.Info().Msg("Running first build")
+	firstPkgs, err := build(path, firstDir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msg("Running second build")
+	secondPkgs, err := build(path, secondDir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	differences, err := comparePackages(firstPkgs, secondPkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(differences) > 0 {
+		for _, d := range differences {
+			log.Error().Str("package", d).Msg("Package is not reproducible")
+		}
+		return nil, fmt.Errorf("%d package(s) are not reproducible: %s", len(differences), strings.Join(differences, ", "))
+	}
+
+	// Move the packages & the other produced files (f.e test report)
+	files, err := ioutil.ReadDir(firstDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if err := os.Rename(filepath.Join(firstDir, f.Name()), filepath.Join(outputDir, f.Name())); err != nil {
+			return nil, err
+		}
+	}
+
+	var pkgs []string
+	for _, p := range firstPkgs {
+		pkgs = append(pkgs, filepath.Join(outputDir, filepath.Base(p)))
+	}
+
+	log.Info().Int("packages", len(pkgs)).Msg("Successfully verified reproducible build")
+	return pkgs, nil
+}
+
+// comparePackages compare the packages produced by two builds
+// this method returns the name of the differing packages
+func comparePackages(first, second []string) ([]string, error) {
+	firstSums, err := checksums(first)
+	if err != nil {
+		return nil, err
+	}
+
+	secondSums, err := checksums(second)
+	if err != nil {
+		return nil, err
+	}
+
+	var differences []string
+	for name, sum := range firstSums {
+		if other, exist := secondSums[name]; !exist || !bytes.Equal(sum, other) {
+			differences = append(differences, name)
+		}
+	}
+	for name := range secondSums {
+		if _, exist := firstSums[name]; !exist {
+			differences = append(differences, name)
+		}
+	}
+	sort.Strings(differences)
+
+	return differences, nil
+}
+
+// checksums returns the sha256 checksum of given files, indexed by file name
+func checksums(paths []string) (map[string][]byte, error) {
+	sums := map[string][]byte{}
+
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		sum := sha256.Sum256(b)
+		sums[filepath.Base(path)] = sum[:]
+	}
+
+	return sums, nil
+}
@@ -0,0 +1,66 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestComparePackages(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"first/a.pkg":  "same",
+		"first/b.pkg":  "first",
+		"first/c.pkg":  "only first",
+		"second/a.pkg": "same",
+		"second/b.pkg": "second",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Error(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0640); err != nil {
+			t.Error(err)
+		}
+	}
+
+	differences, err := comparePackages(
+		[]string{filepath.Join(tmpDir, "first/a.pkg"), filepath.Join(tmpDir, "first/b.pkg"), filepath.Join(tmpDir, "first/c.pkg")},
+		[]string{filepath.Join(tmpDir, "second/a.pkg"), filepath.Join(tmpDir, "second/b.pkg")},
+	)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(differences) != 2 || differences[0] != "b.pkg" || differences[1] != "c.pkg" {
+		t.Errorf("wrong differences (%v)", differences)
+	}
+}
+
+func TestIsWithin(t *testing.T) {
+	tests := []struct {
+		dir      string
+		path     string
+		expected bool
+	}{
+		{"foo", "foo", true},
+		{"foo", "foo/out", true},
+		{"foo", ".", false},
+		{"foo", "foobar", false},
+		{"foo/bar", "foo", false},
+		{"foo", "../foo", false},
+	}
+
+	for _, test := range tests {
+		if got := isWithin(filepath.FromSlash(test.dir), filepath.FromSlash(test.path)); got != test.expected {
+			t.Errorf("isWithin(%s, %s) = %v, want %v", test.dir, test.path, got, test.expected)
+		}
+	}
+}
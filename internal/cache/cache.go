@@ -3,12 +3,20 @@ package cache
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
 )
 
 // Cache represent the installed package cache
 // TODO when managing archive refactor this
 type Cache struct {
 	Packages map[string][]string `json:"packages"`
+	// Versions are the installed packages version
+	Versions map[string]string `json:"versions,omitempty"`
+	// Relations are the installed binary packages relations
+	Relations map[string]control.Relations `json:"relations,omitempty"`
 }
 
 // Read a cache from target path
@@ -17,7 +25,7 @@ func Read(path string) (*Cache, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &Cache{Packages: map[string][]string{}}, nil
+			return &Cache{Packages: map[string][]string{}, Versions: map[string]string{}}, nil
 		}
 		return nil, err
 	}
@@ -26,15 +34,25 @@ func Read(path string) (*Cache, error) {
 		return nil, err
 	}
 
+	// Caches written by older versions have no versions
+	if c.Versions == nil {
+		c.Versions = map[string]string{}
+	}
+
 	return &c, nil
 }
 
 // Write a cache to target path
 func Write(path string, cache *Cache) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+
 	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 
 	if err := json.NewEncoder(f).Encode(*cache); err != nil {
 		return err
@@ -53,7 +71,59 @@ func (c *Cache) AddPackage(pkg string, files []string) {
 	c.Packages[pkg] = files
 }
 
+// GetVersion return the installed version of given package
+// return an empty string if the version is unknown
+func (c *Cache) GetVersion(pkg string) string {
+	return c.Versions[pkg]
+}
+
+// SetVersion set the installed version of given package
+func (c *Cache) SetVersion(pkg, version string) {
+	if c.Versions == nil {
+		c.Versions = map[string]string{}
+	}
+	c.Versions[pkg] = version
+}
+
 // RemovePackage remove given package from cache
 func (c *Cache) RemovePackage(pkg string) {
 	delete(c.Packages, pkg)
+	delete(c.Versions, pkg)
+	delete(c.Relations, pkg)
+}
+
+// GetRelations return the relations of given package
+func (c *Cache) GetRelations(pkg string) control.Relations {
+	return c.Relations[pkg]
+}
+
+// SetRelations set the relations of given package
+func (c *Cache) SetRelations(pkg string, relations control.Relations) {
+	if c.Relations == nil {
+		c.Relations = map[string]control.Relations{}
+	}
+	c.Relations[pkg] = relations
+}
+
+// Providers return the installed packages satisfying given dependency
+// either by name (and version) or by providing it
+func (c *Cache) Providers(d control.Dependency) []string {
+	var providers []string
+	for pkg := range c.Packages {
+		if d.Match(pkg, c.Versions[pkg]) {
+			providers = append(providers, pkg)
+			continue
+		}
+
+		for _, provide := range c.Relations[pkg].Provides {
+			p, err := control.ParseDependency(provide)
+			if err == nil && p.Name == d.Name && (d.Operator == "" || d.Match(p.Name, p.Version)) {
+				providers = append(providers, pkg)
+				break
+			}
+		}
+	}
+	sort.Strings(providers)
+
+	return providers
 }
@@ -1,18 +1,64 @@
 package cache
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+)
 
 func TestCache(t *testing.T) {
-	c := Cache{map[string][]string{}}
+	c := Cache{Packages: map[string][]string{}}
 	c.AddPackage("gohello", []string{"bin/gohello"})
+	c.SetVersion("gohello", "1.0.0-1")
 
 	if c.GetFiles("gohello")[0] != "bin/gohello" {
 		t.Error()
 	}
 
+	if c.GetVersion("gohello") != "1.0.0-1" {
+		t.Error()
+	}
+
 	c.RemovePackage("gohello")
 
-	if c.GetFiles("gohello") != nil {
+	if c.GetFiles("gohello") != nil || c.GetVersion("gohello") != "" {
 		t.Error()
 	}
 }
+
+func TestCache_Providers(t *testing.T) {
+	c := Cache{Packages: map[string][]string{}}
+	c.AddPackage("protoc", nil)
+	c.SetVersion("protoc", "3.12.0-1")
+	c.AddPackage("protoc-gen-go", nil)
+	c.SetVersion("protoc-gen-go", "1.25.0-1")
+	c.SetRelations("protoc-gen-go", control.Relations{Provides: []string{"protoc-gen (= 1.0)", "protoc-plugin"}})
+
+	tests := []struct {
+		Dependency string
+		Expected   []string
+	}{
+		{"protoc (>= 3.0)", []string{"protoc"}},
+		{"protoc (>= 4.0)", nil},
+		{"protoc-gen (>= 1.0)", []string{"protoc-gen-go"}},
+		{"protoc-plugin", []string{"protoc-gen-go"}},
+		{"protoc-plugin (>= 1.0)", nil},
+		{"protoc-gen-go", []string{"protoc-gen-go"}},
+	}
+
+	for _, test := range tests {
+		d, err := control.ParseDependency(test.Dependency)
+		if err != nil {
+			t.Fatal(err)
+		}
+		providers := c.Providers(d)
+		if len(providers) != len(test.Expected) || (len(providers) > 0 && providers[0] != test.Expected[0]) {
+			t.Errorf("wrong providers of %s (%v)", test.Dependency, providers)
+		}
+	}
+
+	c.RemovePackage("protoc-gen-go")
+	if len(c.GetRelations("protoc-gen-go").Provides) != 0 {
+		t.Error("relations should have been removed")
+	}
+}
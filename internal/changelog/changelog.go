@@ -0,0 +1,74 @@
+package changelog
+
+import (
+	"fmt"
+
+	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/util"
+	"github.com/rs/zerolog/log"
+)
+
+// NewRelease add a new release to the changelog of the control directory at given path
+// the package revision is bumped, unless a new upstream version is given
+// distribution & urgency are optional
+func NewRelease(path, upstreamVersion, distribution, urgency string) error {
+	if urgency != "" && !util.Contains(control.Urgencies, urgency) {
+		return fmt.Errorf("invalid urgency: %s (expected one of %v)", urgency, control.Urgencies)
+	}
+
+	config, err := config.Default()
+	if err != nil {
+		return err
+	}
+
+	m, c, err := control.ReadCtrlDirectory(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.NewRelease(upstreamVersion, config.GetMaintainerEntry()); err != nil {
+		return err
+	}
+
+	release := &c.Releases[len(c.Releases)-1]
+	if distribution != "" {
+		release.Distribution = distribution
+	}
+	release.Urgency = urgency
+
+	if upstreamVersion != "" {
+		release.Changes = append(release.Changes, fmt.Sprintf("New upstream release %s", release.UpstreamVersion()))
+	}
+
+	if err := control.UpdateCtrlDirectory(path, m, c); err != nil {
+		return err
+	}
+
+	log.Info().Str("version", release.Version).Msg("Successfully created release")
+	return nil
+}
+
+// Add add the given change to the latest release of the control directory at given path
+func Add(path, change string) error {
+	m, c, err := control.ReadCtrlDirectory(path)
+	if err != nil {
+		return err
+	}
+
+	if err := c.AddChange(change); err != nil {
+		return err
+	}
+
+	if err := control.UpdateCtrlDirectory(path, m, c); err != nil {
+		return err
+	}
+
+	latest, err := c.LatestRelease()
+	if err != nil {
+		return err
+	}
+
+	log.Info().Str("version", latest.Version).Str("change", change).Msg("Successfully added change")
+	return nil
+}
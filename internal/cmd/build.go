@@ -9,17 +9,32 @@ import (
 
 // ExecBuild execute the `gopkg build` command
 func ExecBuild(c *cli.Context) error {
-	path := c.Args().First()
-	if path == "" {
-		path = "."
-	}
-
-	absolutePath, err := getAbsolutePath(path)
+	absolutePath, err := getCtrlPath(c.Args().First())
 	if err != nil {
 		return err
 	}
 
-	return build.Build(absolutePath)
+	return build.Build(absolutePath, build.Options{
+		OutputDir:          c.String("output"),
+		PristineSource:     c.Bool("pristine-source"),
+		Jobs:               c.Int("jobs"),
+		FailFast:           c.Bool("fail-fast"),
+		VerifyReproducible: c.Bool("verify-reproducible"),
+		Profile:            c.String("profile"),
+		Hermetic:           c.Bool("hermetic"),
+		SkipTests:          c.Bool("skip-tests"),
+		NoCache:            c.Bool("no-cache"),
+	})
+}
+
+// getCtrlPath returns the absolute path to given control directory
+// defaulting to the current directory
+func getCtrlPath(path string) (string, error) {
+	if path == "" {
+		path = "."
+	}
+
+	return getAbsolutePath(path)
 }
 
 func getAbsolutePath(path string) (string, error) {
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/go-pkg-org/gopkg/internal/build"
+	"github.com/urfave/cli/v2"
+)
+
+// ExecBuildCachePrune execute the `gopkg build-cache prune` command
+func ExecBuildCachePrune(c *cli.Context) error {
+	maxAge := c.Duration("max-age")
+	if c.Bool("all") {
+		maxAge = 0
+	}
+
+	return build.PruneCache(maxAge)
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/go-pkg-org/gopkg/internal/changelog"
+	"github.com/urfave/cli/v2"
+)
+
+// ExecChangelogNewRelease execute the `gopkg changelog new-release` command
+func ExecChangelogNewRelease(c *cli.Context) error {
+	path, err := getCtrlPath(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	return changelog.NewRelease(path, c.String("upstream"), c.String("distribution"), c.String("urgency"))
+}
+
+// ExecChangelogAdd execute the `gopkg changelog add` command
+func ExecChangelogAdd(c *cli.Context) error {
+	if !c.Args().Present() {
+		return fmt.Errorf("missing message")
+	}
+
+	path, err := getCtrlPath(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	return changelog.Add(path, c.Args().First())
+}
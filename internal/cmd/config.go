@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+// SetConfigPath set the configuration file from the global --config flag
+func SetConfigPath(c *cli.Context) error {
+	if path := c.String("config"); path != "" {
+		config.SetPath(path)
+	}
+
+	return nil
+}
+
+// ExecConfigGet execute the `gopkg config get` command
+func ExecConfigGet(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: gopkg config get <key>")
+	}
+
+	s, err := config.Get(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(s.Value)
+	return nil
+}
+
+// ExecConfigSet execute the `gopkg config set` command
+func ExecConfigSet(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("usage: gopkg config set <key> <value>")
+	}
+
+	return config.Set(c.Args().Get(0), c.Args().Get(1))
+}
+
+// ExecConfigList execute the `gopkg config list` command
+func ExecConfigList(c *cli.Context) error {
+	settings, err := config.Settings()
+	if err != nil {
+		return err
+	}
+
+	// Keep stdout clean so the list can be piped
+	log.Logger = log.Logger.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	// One setting per line: key, source & value separated by tabs
+	for _, s := range settings {
+		fmt.Printf("%s\t%s\t%s\n", s.Key, s.Source, s.Value)
+	}
+
+	conf, err := config.Default()
+	if err != nil {
+		return err
+	}
+	if err := conf.Validate(); err != nil {
+		log.Warn().Str("err", err.Error()).Msg("Invalid configuration")
+	}
+
+	return nil
+}
+
+// ExecConfigEdit execute the `gopkg config edit` command
+func ExecConfigEdit(c *cli.Context) error {
+	return config.Edit()
+}
+
+// ExecConfigPath execute the `gopkg config path` command
+func ExecConfigPath(c *cli.Context) error {
+	path, err := config.Path()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(path)
+	return nil
+}
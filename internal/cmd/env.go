@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/go-pkg-org/gopkg/internal/env"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+// ExecEnv execute the `gopkg env` command
+func ExecEnv(c *cli.Context) error {
+	scope, err := getScope(c)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("check") {
+		return env.Check(scope)
+	}
+
+	// Keep stdout clean so the output can be evaluated by the shell
+	log.Logger = log.Logger.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	return env.Env(c.String("shell"), scope)
+}
+
+// ExecShellInit execute the `gopkg shell-init` command
+func ExecShellInit(c *cli.Context) error {
+	return env.ShellInit(c.String("shell"))
+}
@@ -0,0 +1,11 @@
+package cmd
+
+import (
+	"github.com/go-pkg-org/gopkg/internal/info"
+	"github.com/urfave/cli/v2"
+)
+
+// ExecInfo execute the `gopkg info` command
+func ExecInfo(c *cli.Context) error {
+	return info.Info(c.Args().First())
+}
@@ -12,5 +12,13 @@ func ExecInstall(c *cli.Context) error {
 		return fmt.Errorf("missing pkg-path")
 	}
 
-	return install.Install(c.Args().First())
+	scope, err := getScope(c)
+	if err != nil {
+		return err
+	}
+
+	return install.Install(c.Args().First(), install.Options{
+		Scope:     scope,
+		NoScripts: c.Bool("no-scripts"),
+	})
 }
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/go-pkg-org/gopkg/internal/lint"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+// ExecLint execute the `gopkg lint` command
+func ExecLint(c *cli.Context) error {
+	path, err := getCtrlPath(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	// Keep stdout clean for the JSON report
+	if c.Bool("json") {
+		log.Logger = log.Logger.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	}
+
+	return lint.Lint(path, c.Bool("json"))
+}
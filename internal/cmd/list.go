@@ -7,5 +7,10 @@ import (
 
 // ExecList execute the `gopkg list` command
 func ExecList(c *cli.Context) error {
-	return list.List(c.Bool("installed"))
+	scope, err := getScope(c)
+	if err != nil {
+		return err
+	}
+
+	return list.List(c.Bool("installed"), scope)
 }
@@ -12,5 +12,9 @@ func ExecMake(c *cli.Context) error {
 		return fmt.Errorf("missing import-path")
 	}
 
-	return make2.Make(c.Args().First())
+	if c.Bool("update") {
+		return make2.Update(c.Args().First())
+	}
+
+	return make2.Make(c.Args().First(), c.Bool("group"))
 }
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/go-pkg-org/gopkg/internal/patch"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+// ExecPatchNew execute the `gopkg patch new` command
+func ExecPatchNew(c *cli.Context) error {
+	if !c.Args().Present() {
+		return fmt.Errorf("missing patch-name")
+	}
+
+	path, err := getCtrlPath(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	return patch.New(path, c.Args().First())
+}
+
+// ExecPatchRefresh execute the `gopkg patch refresh` command
+func ExecPatchRefresh(c *cli.Context) error {
+	path, err := getCtrlPath(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	return patch.Refresh(path, c.Args().First())
+}
+
+// ExecPatchApply execute the `gopkg patch apply` command
+func ExecPatchApply(c *cli.Context) error {
+	path, err := getCtrlPath(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	applied, err := patch.Apply(path)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Strs("patches", applied).Msg("Successfully applied patches")
+	return nil
+}
+
+// ExecPatchRevert execute the `gopkg patch revert` command
+func ExecPatchRevert(c *cli.Context) error {
+	path, err := getCtrlPath(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	patches, err := patch.ReadSeries(path)
+	if err != nil {
+		return err
+	}
+
+	if err := patch.Revert(path, patches); err != nil {
+		return err
+	}
+
+	log.Info().Strs("patches", patches).Msg("Successfully reverted patches")
+	return nil
+}
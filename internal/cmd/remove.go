@@ -12,5 +12,13 @@ func ExecRemove(c *cli.Context) error {
 		return fmt.Errorf("missing pkg-name")
 	}
 
-	return remove.Remove(c.Args().First())
+	scope, err := getScope(c)
+	if err != nil {
+		return err
+	}
+
+	return remove.Remove(c.Args().First(), remove.Options{
+		Scope:     scope,
+		NoScripts: c.Bool("no-scripts"),
+	})
 }
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/urfave/cli/v2"
+)
+
+// ExecSchema execute the `gopkg schema` command
+func ExecSchema(c *cli.Context) error {
+	var b []byte
+	var err error
+
+	switch file := c.Args().First(); file {
+	case "metadata":
+		b, err = control.MetadataSchema()
+	case "changelog":
+		b, err = control.ChangelogSchema()
+	default:
+		return fmt.Errorf("unknown control file: %s (expected metadata or changelog)", file)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(b)
+	return err
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/urfave/cli/v2"
+)
+
+// ScopeFlags are the flags used to select the install scope
+var ScopeFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:  "user",
+		Usage: "use the user scope (~/.gopkg)",
+	},
+	&cli.BoolFlag{
+		Name:  "system",
+		Usage: "use the system scope (system_prefix, default to /usr/local or Program Files\\gopkg on windows)",
+	},
+	&cli.BoolFlag{
+		Name:  "project",
+		Usage: "use the project scope (.gopkg next to the go.mod)",
+	},
+}
+
+// getScope returns the scope selected using the scope flags, empty if none
+func getScope(c *cli.Context) (config.Scope, error) {
+	var scope config.Scope
+	for _, s := range config.Scopes {
+		if !c.Bool(string(s)) {
+			continue
+		}
+		if scope != "" {
+			return "", fmt.Errorf("--%s and --%s are mutually exclusive", scope, s)
+		}
+		scope = s
+	}
+
+	return scope, nil
+}
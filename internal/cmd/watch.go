@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/go-pkg-org/gopkg/internal/watch"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+// ExecWatch execute the `gopkg watch` command
+func ExecWatch(c *cli.Context) error {
+	paths := c.Args().Slice()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	// Keep stdout clean for the JSON report
+	if c.Bool("json") {
+		log.Logger = log.Logger.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	}
+
+	return watch.Watch(paths, c.String("source"), c.Bool("json"))
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"os/user"
 	"path/filepath"
 
@@ -16,6 +17,9 @@ const GoPkgDir = ".gopkg"
 
 const configFile = ".gopkg.yaml"
 
+// configEnv is the environment variable used to set the configuration file
+const configEnv = "GOPKG_CONFIG"
+
 // Maintainer is the object containg info about the maintainer.
 type Maintainer struct {
 	Email string `yaml:"email" envconfig:"email"`
@@ -28,56 +32,148 @@ type Config struct {
 	CachePath  string     `yaml:"cache_path" envconfig:"cache_path"`
 	Maintainer Maintainer `yaml:"maintainer" envconfig:"maintainer"`
 	SrcDir     string     `yaml:"src_dir"  envconfig:"src_dir"`
+	// BuildCacheDir is the directory where built binary packages are cached
+	BuildCacheDir string `yaml:"build_cache_dir" envconfig:"build_cache_dir"`
+	// ShareDir is the directory where the man pages, shell completions & docs are installed
+	ShareDir string `yaml:"share_dir" envconfig:"share_dir"`
+	// EtcDir is the directory where the configuration files are installed
+	EtcDir string `yaml:"etc_dir" envconfig:"etc_dir"`
+	// SystemPrefix is the prefix of the system install scope (default to /usr/local, Program Files\gopkg on windows)
+	SystemPrefix string `yaml:"system_prefix" envconfig:"system_prefix"`
+	// Scope is the install scope the directories belong to
+	Scope Scope `yaml:"-" ignored:"true"`
+	// HooksDir is the directory where the remove hooks of the installed packages are kept
+	HooksDir string `yaml:"hooks_dir" envconfig:"hooks_dir"`
+	// GoFlags are the GOFLAGS exported by gopkg env
+	GoFlags string `yaml:"go_flags" envconfig:"go_flags"`
+	// Proxy is the local module proxy URL exported as GOPROXY by gopkg env (disabled if empty)
+	Proxy string `yaml:"proxy" envconfig:"proxy"`
+	// Repositories are the directories where packages are looked up (f.e build dependencies)
+	Repositories []string `yaml:"repositories" envconfig:"repositories"`
+	// DefaultTargets are the targets (os, arches) used by new packages
+	DefaultTargets map[string][]string `yaml:"default_targets" ignored:"true"`
 }
 
-// Load loads the configuration file from the users home directory.
-func (c *Config) load() error {
+// explicitPath is the configuration file set using SetPath
+var explicitPath string
+
+// SetPath set the configuration file to use instead of ~/.gopkg.yaml (f.e from the --config flag)
+func SetPath(path string) {
+	explicitPath = path
+}
+
+// Path returns the configuration file path
+// the file set using SetPath or GOPKG_CONFIG takes precedence over ~/.gopkg.yaml (or .yml)
+func Path() (string, error) {
+	path, _, err := getPath()
+	return path, err
+}
+
+// getPath returns the configuration file path & whether it was explicitly set
+func getPath() (string, bool, error) {
+	if explicitPath != "" {
+		return explicitPath, true, nil
+	}
+	if path := os.Getenv(configEnv); path != "" {
+		return path, true, nil
+	}
+
 	u, err := user.Current()
 	if err != nil {
+		return "", false, err
+	}
+
+	path := filepath.Join(u.HomeDir, configFile)
+	if found, err := file.FindByExtensions(path, []string{"yaml", "yml"}); err == nil {
+		path = found
+	}
+
+	return path, false, nil
+}
+
+// Load loads the configuration file & the environment variables.
+func (c *Config) load() error {
+	if err := c.loadFile(); err != nil {
 		return err
 	}
 
-	path, err := file.FindByExtensions(filepath.Join(u.HomeDir, configFile), []string{"yaml", "yml"})
-	if err == nil {
-		out, err := ioutil.ReadFile(path)
-		if err != nil {
-			return err
-		}
+	return c.loadEnv()
+}
 
-		err = yaml.Unmarshal([]byte(out), &c)
-		if err != nil {
-			return err
-		}
+// loadFile loads the configuration file, an explicitly set file must exist
+func (c *Config) loadFile() error {
+	path, explicit, err := getPath()
+	if err != nil {
+		return err
 	}
 
-	err = envconfig.Process("gopkg", c)
+	out, err := ioutil.ReadFile(path)
 	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil
+		}
 		return err
 	}
 
+	if err := yaml.Unmarshal(out, c); err != nil {
+		return fmt.Errorf("invalid configuration file %s: %s", path, err)
+	}
+
 	return nil
 }
 
+// loadEnv loads the GOPKG_* environment variables
+func (c *Config) loadEnv() error {
+	return envconfig.Process("gopkg", c)
+}
+
 // Default returns a default configuration.
 func Default() (*Config, error) {
-	u, err := user.Current()
+	c, err := defaults()
 	if err != nil {
 		return nil, err
 	}
 
-	c := &Config{
-		BinDir:    filepath.Join(u.HomeDir, GoPkgDir, "bin"),
-		CachePath: filepath.Join(u.HomeDir, GoPkgDir, "cache.json"),
-		SrcDir:    filepath.Join(u.HomeDir, GoPkgDir, "src"),
+	if err := c.load(); err != nil {
+		return nil, err
 	}
+	c.setDefaultTargets()
 
-	if err := c.load(); err != nil {
+	return c, nil
+}
+
+// defaults returns the built-in configuration, without the configuration file & environment
+func defaults() (*Config, error) {
+	u, err := user.Current()
+	if err != nil {
 		return nil, err
 	}
 
+	c := &Config{
+		BinDir:        filepath.Join(u.HomeDir, GoPkgDir, "bin"),
+		CachePath:     filepath.Join(u.HomeDir, GoPkgDir, "cache.json"),
+		SrcDir:        filepath.Join(u.HomeDir, GoPkgDir, "src"),
+		BuildCacheDir: filepath.Join(u.HomeDir, GoPkgDir, "build-cache"),
+		HooksDir:      filepath.Join(u.HomeDir, GoPkgDir, "hooks"),
+		ShareDir:      filepath.Join(u.HomeDir, GoPkgDir, "share"),
+		EtcDir:        filepath.Join(u.HomeDir, GoPkgDir, "etc"),
+		Scope:         UserScope,
+	}
+
 	return c, nil
 }
 
+// setDefaultTargets set the built-in default targets if none are configured
+// this is done once loaded since yaml would merge the configured targets into the built-in ones
+func (c *Config) setDefaultTargets() {
+	if c.DefaultTargets == nil {
+		c.DefaultTargets = map[string][]string{
+			"linux":  {"amd64"},
+			"darwin": {"amd64"},
+		}
+	}
+}
+
 // GetGoPathDir returns GOPATH variable
 func (c *Config) GetGoPathDir() (string, error) {
 	return filepath.Join(c.SrcDir, ".."), nil
@@ -119,4 +119,32 @@ func TestConfigDefault(t *testing.T) {
 			t.Errorf("Config %s actual value [%s] is not equal to expected [%s]", test.Text, test.Actual, test.Expected)
 		}
 	}
+
+	if len(config.DefaultTargets) != 2 || config.DefaultTargets["linux"][0] != "amd64" || config.DefaultTargets["darwin"][0] != "amd64" {
+		t.Errorf("Config default targets not equal to expected, got %v", config.DefaultTargets)
+	}
+}
+
+func TestConfigDefaultTargetsReplaced(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopkg-config-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "gopkg.yaml")
+	if err := ioutil.WriteFile(path, []byte("default_targets:\n  linux: [arm64]\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	SetPath(path)
+	defer SetPath("")
+
+	c, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.DefaultTargets) != 1 || len(c.DefaultTargets["linux"]) != 1 || c.DefaultTargets["linux"][0] != "arm64" {
+		t.Errorf("configured default targets should replace the built-in ones, got %v", c.DefaultTargets)
+	}
 }
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Scope is where packages are installed, each scope has its own installed packages database
+type Scope string
+
+const (
+	// UserScope install the packages in the user home directory (~/.gopkg)
+	UserScope Scope = "user"
+	// SystemScope install the packages system-wide, under the system prefix (f.e /usr/local)
+	SystemScope Scope = "system"
+	// ProjectScope install the packages in the current project (./.gopkg next to the go.mod)
+	ProjectScope Scope = "project"
+)
+
+// Scopes are the install scopes, in resolution order (most specific first)
+var Scopes = []Scope{ProjectScope, UserScope, SystemScope}
+
+// warnedOverrides are the overridden install directories already reported, to report them once
+var warnedOverrides = map[string]bool{}
+
+// ForScope returns the configuration of given install scope (the user scope if empty)
+// the install directories (binaries, database, sources...) of the system & project scopes are derived
+// from the scope root: the bin_dir, cache_path, src_dir, share_dir, etc_dir & hooks_dir values
+// of the configuration file & environment only apply to the user scope
+func ForScope(scope Scope) (*Config, error) {
+	c, err := Default()
+	if err != nil {
+		return nil, err
+	}
+
+	if scope == SystemScope || scope == ProjectScope {
+		if err := warnOverriddenDirs(c, scope); err != nil {
+			return nil, err
+		}
+	}
+
+	switch scope {
+	case "", UserScope:
+		return c, nil
+	case SystemScope:
+		prefix := c.SystemPrefix
+		if prefix == "" {
+			prefix = defaultSystemPrefix()
+		}
+		c.setInstallDirs(prefix, filepath.Join(prefix, "lib", "gopkg"))
+	case ProjectScope:
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		root, err := findProjectRoot(wd)
+		if err != nil {
+			return nil, err
+		}
+		dir := filepath.Join(root, GoPkgDir)
+		c.setInstallDirs(dir, dir)
+	default:
+		return nil, fmt.Errorf("invalid scope %s (supported: %v)", scope, Scopes)
+	}
+
+	c.Scope = scope
+	return c, nil
+}
+
+// defaultSystemPrefix returns the default prefix of the system scope
+// /usr/local, or the gopkg directory of Program Files on windows
+func defaultSystemPrefix() string {
+	if runtime.GOOS != "windows" {
+		return "/usr/local"
+	}
+
+	programFiles := os.Getenv("ProgramFiles")
+	if programFiles == "" {
+		programFiles = `C:\Program Files`
+	}
+	return filepath.Join(programFiles, "gopkg")
+}
+
+// warnOverriddenDirs warn about the configured install directories ignored by given scope
+func warnOverriddenDirs(c *Config, scope Scope) error {
+	d, err := defaults()
+	if err != nil {
+		return err
+	}
+
+	dirs := []struct {
+		key        string
+		value      string
+		defaultDir string
+	}{
+		{"bin_dir", c.BinDir, d.BinDir},
+		{"cache_path", c.CachePath, d.CachePath},
+		{"src_dir", c.SrcDir, d.SrcDir},
+		{"share_dir", c.ShareDir, d.ShareDir},
+		{"etc_dir", c.EtcDir, d.EtcDir},
+		{"hooks_dir", c.HooksDir, d.HooksDir},
+	}
+	for _, dir := range dirs {
+		if dir.value == dir.defaultDir || warnedOverrides[string(scope)+dir.key] {
+			continue
+		}
+
+		warnedOverrides[string(scope)+dir.key] = true
+		log.Warn().Str("scope", string(scope)).Str("key", dir.key).Msg("Configured directory only applies to the user scope, ignoring")
+	}
+
+	return nil
+}
+
+// setInstallDirs set the install directories
+// prefix hold the installed files (bin, share, etc), dataDir the gopkg files (database, sources, hooks)
+func (c *Config) setInstallDirs(prefix, dataDir string) {
+	c.BinDir = filepath.Join(prefix, "bin")
+	c.ShareDir = filepath.Join(prefix, "share")
+	c.EtcDir = filepath.Join(prefix, "etc")
+	c.CachePath = filepath.Join(dataDir, "cache.json")
+	c.SrcDir = filepath.Join(dataDir, "src")
+	c.HooksDir = filepath.Join(dataDir, "hooks")
+}
+
+// findProjectRoot returns the nearest directory containing a go.mod, starting from dir
+func findProjectRoot(dir string) (string, error) {
+	for current := dir; ; current = filepath.Dir(current) {
+		if _, err := os.Stat(filepath.Join(current, "go.mod")); err == nil {
+			return current, nil
+		}
+
+		if current == filepath.Dir(current) {
+			return "", fmt.Errorf("no go.mod found in %s or its parents", dir)
+		}
+	}
+}
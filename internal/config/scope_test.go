@@ -0,0 +1,67 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestForScope(t *testing.T) {
+	prefix := filepath.Join(os.TempDir(), "gopkg-system")
+	os.Setenv("GOPKG_SYSTEM_PREFIX", prefix)
+	defer os.Unsetenv("GOPKG_SYSTEM_PREFIX")
+
+	c, err := ForScope(SystemScope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Scope != SystemScope || c.BinDir != filepath.Join(prefix, "bin") ||
+		c.CachePath != filepath.Join(prefix, "lib", "gopkg", "cache.json") ||
+		c.ShareDir != filepath.Join(prefix, "share") || c.HooksDir != filepath.Join(prefix, "lib", "gopkg", "hooks") {
+		t.Errorf("wrong system scope config (%+v)", c)
+	}
+
+	c, err = ForScope("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Scope != UserScope || filepath.Base(filepath.Dir(c.BinDir)) != GoPkgDir {
+		t.Errorf("wrong user scope config (%+v)", c)
+	}
+
+	// The user scope directories do not apply to the system scope
+	os.Setenv("GOPKG_BIN_DIR", filepath.Join(os.TempDir(), "bin"))
+	defer os.Unsetenv("GOPKG_BIN_DIR")
+	if c, err := ForScope(SystemScope); err != nil || c.BinDir != filepath.Join(prefix, "bin") {
+		t.Errorf("wrong system scope bin dir (%+v, %v)", c, err)
+	}
+
+	if _, err := ForScope("global"); err == nil {
+		t.Error("ForScope should have failed")
+	}
+}
+
+func TestFindProjectRoot(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dir := filepath.Join(tmpDir, "cmd", "foo")
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		t.Error(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module foo\n"), 0640); err != nil {
+		t.Error(err)
+	}
+
+	if root, err := findProjectRoot(dir); err != nil || root != tmpDir {
+		t.Errorf("wrong project root (%s, %v)", root, err)
+	}
+
+	if _, err := findProjectRoot(filepath.Dir(tmpDir)); err == nil {
+		t.Error("findProjectRoot should have failed")
+	}
+}
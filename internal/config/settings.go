@@ -0,0 +1,377 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/mail"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Sources of the configuration values
+const (
+	// DefaultSource is the built-in value
+	DefaultSource = "default"
+	// FileSource is a value from the configuration file
+	FileSource = "file"
+	// EnvSource is a value from a GOPKG_* environment variable
+	EnvSource = "env"
+)
+
+// Setting is a configuration value along with where it comes from
+type Setting struct {
+	// Key is the configuration file key (f.e bin_dir, maintainer.email)
+	Key string
+	// Value is the textual value, lists & maps are JSON encoded
+	Value  string
+	Source string
+}
+
+// Settings returns the effective configuration values, in configuration file order
+func Settings() ([]Setting, error) {
+	// The default targets are only set once loaded, see setDefaultTargets
+	withDefaultTargets := func(c Config) reflect.Value {
+		c.setDefaultTargets()
+		return reflect.ValueOf(c)
+	}
+
+	c, err := defaults()
+	if err != nil {
+		return nil, err
+	}
+	defaultValues := flatten("", withDefaultTargets(*c))
+
+	if err := c.loadFile(); err != nil {
+		return nil, err
+	}
+	fileValues := flatten("", withDefaultTargets(*c))
+
+	fileKeys, err := readFileKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.loadEnv(); err != nil {
+		return nil, err
+	}
+	c.setDefaultTargets()
+	settings := flatten("", reflect.ValueOf(c).Elem())
+
+	for i := range settings {
+		switch {
+		case settings[i].Value != fileValues[i].Value:
+			settings[i].Source = EnvSource
+		case fileKeys[settings[i].Key] || fileValues[i].Value != defaultValues[i].Value:
+			settings[i].Source = FileSource
+		default:
+			settings[i].Source = DefaultSource
+		}
+	}
+
+	return settings, nil
+}
+
+// Get returns the effective value of given key
+func Get(key string) (Setting, error) {
+	settings, err := Settings()
+	if err != nil {
+		return Setting{}, err
+	}
+
+	for _, s := range settings {
+		if s.Key == key {
+			return s, nil
+		}
+	}
+
+	return Setting{}, fmt.Errorf("unknown configuration key %s", key)
+}
+
+// Set write given value in the configuration file, the file is created if needed
+// lists & maps values are parsed as YAML (f.e [/srv/repo, /tmp/repo])
+// the file is left untouched if the resulting configuration is invalid
+// the file is rewritten as a whole, so its comments are not kept
+func Set(key, value string) error {
+	field, exist := lookupField(reflect.TypeOf(Config{}), key)
+	if !exist || field.Type.Kind() == reflect.Struct {
+		return fmt.Errorf("unknown configuration key %s", key)
+	}
+
+	var v interface{} = value
+	if field.Type.Kind() != reflect.String {
+		if err := yaml.Unmarshal([]byte(value), &v); err != nil {
+			return fmt.Errorf("invalid value for %s: %s", key, err)
+		}
+	}
+
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	content, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	content = setValue(content, strings.Split(key, "."), v)
+
+	out, err := yaml.Marshal(content)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the resulting configuration is valid before writing it
+	c, err := defaults()
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(out, c); err != nil {
+		return fmt.Errorf("invalid value for %s: %s", key, err)
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, out, 0640)
+}
+
+// Edit open the configuration file in the user editor ($VISUAL, $EDITOR or vi) & validate it afterwards
+// the file is created if needed
+func Edit() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, nil, 0640); err != nil {
+			return err
+		}
+	}
+
+	editor := strings.Fields(getEditor())
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error while running editor: %s", err)
+	}
+
+	c, err := Default()
+	if err != nil {
+		return err
+	}
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration %s: %s", path, err)
+	}
+
+	return nil
+}
+
+// Validate make sure the configuration values are usable
+func (c *Config) Validate() error {
+	dirs := []struct {
+		key   string
+		value string
+	}{
+		{"bin_dir", c.BinDir},
+		{"cache_path", c.CachePath},
+		{"src_dir", c.SrcDir},
+		{"build_cache_dir", c.BuildCacheDir},
+		{"share_dir", c.ShareDir},
+		{"etc_dir", c.EtcDir},
+		{"hooks_dir", c.HooksDir},
+	}
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir.value) {
+			return fmt.Errorf("%s must be an absolute path: %q", dir.key, dir.value)
+		}
+	}
+
+	if c.SystemPrefix != "" && !filepath.IsAbs(c.SystemPrefix) {
+		return fmt.Errorf("system_prefix must be an absolute path: %q", c.SystemPrefix)
+	}
+
+	for _, repository := range c.Repositories {
+		if !filepath.IsAbs(repository) {
+			return fmt.Errorf("repositories must be absolute paths: %q", repository)
+		}
+	}
+
+	if c.Maintainer.Email != "" {
+		if addr, err := mail.ParseAddress(c.Maintainer.Email); err != nil || addr.Address != c.Maintainer.Email {
+			return fmt.Errorf("invalid maintainer.email: %s", c.Maintainer.Email)
+		}
+	}
+
+	if c.Proxy != "" {
+		if u, err := url.Parse(c.Proxy); err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "file") {
+			return fmt.Errorf("invalid proxy (must be an http, https or file URL): %s", c.Proxy)
+		}
+	}
+
+	return nil
+}
+
+// flatten returns the values of given configuration struct, nested structs keys are joined with a dot
+func flatten(prefix string, v reflect.Value) []Setting {
+	var settings []Setting
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if name == "" {
+			continue
+		}
+
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.Struct:
+			settings = append(settings, flatten(prefix+name+".", f)...)
+		case reflect.String:
+			settings = append(settings, Setting{Key: prefix + name, Value: f.String()})
+		default:
+			value := ""
+			if f.Len() > 0 {
+				b, _ := json.Marshal(f.Interface())
+				value = string(b)
+			}
+			settings = append(settings, Setting{Key: prefix + name, Value: value})
+		}
+	}
+
+	return settings
+}
+
+// lookupField returns the struct field of given key (nested keys are joined with a dot)
+func lookupField(t reflect.Type, key string) (reflect.StructField, bool) {
+	parts := strings.SplitN(key, ".", 2)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if yamlName(f) != parts[0] {
+			continue
+		}
+
+		if len(parts) == 1 {
+			return f, true
+		}
+		if f.Type.Kind() == reflect.Struct {
+			return lookupField(f.Type, parts[1])
+		}
+
+		return reflect.StructField{}, false
+	}
+
+	return reflect.StructField{}, false
+}
+
+// yamlName returns the configuration file key of given field, empty if the field is not configurable
+func yamlName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+// readFileKeys returns the keys set in the configuration file
+func readFileKeys() (map[string]bool, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]bool{}
+	var walk func(prefix string, content yaml.MapSlice)
+	walk = func(prefix string, content yaml.MapSlice) {
+		for _, item := range content {
+			key := prefix + fmt.Sprint(item.Key)
+			nested, isMap := item.Value.(yaml.MapSlice)
+			if f, exist := lookupField(reflect.TypeOf(Config{}), key); exist && isMap && f.Type.Kind() == reflect.Struct {
+				walk(key+".", nested)
+				continue
+			}
+			keys[key] = true
+		}
+	}
+	walk("", content)
+
+	return keys, nil
+}
+
+// readFile returns the content of the configuration file, empty if the file does not exist
+func readFile(path string) (yaml.MapSlice, error) {
+	var content yaml.MapSlice
+
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return content, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(out, &content); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %s", path, err)
+	}
+
+	return content, nil
+}
+
+// setValue set the value at given key path, keeping the other entries order
+func setValue(content yaml.MapSlice, path []string, value interface{}) yaml.MapSlice {
+	for i, item := range content {
+		if fmt.Sprint(item.Key) != path[0] {
+			continue
+		}
+
+		if len(path) == 1 {
+			content[i].Value = value
+		} else {
+			nested, _ := item.Value.(yaml.MapSlice)
+			content[i].Value = setValue(nested, path[1:], value)
+		}
+		return content
+	}
+
+	if len(path) == 1 {
+		return append(content, yaml.MapItem{Key: path[0], Value: value})
+	}
+
+	return append(content, yaml.MapItem{Key: path[0], Value: setValue(nil, path[1:], value)})
+}
+
+func getEditor() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(env)); editor != "" {
+			return editor
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopkg-config-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "gopkg.yaml")
+	SetPath(path)
+	defer SetPath("")
+
+	if err := Set("maintainer.email", "me@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	repositories := []string{filepath.Join(dir, "a"), filepath.Join(dir, "b")}
+	if err := Set("repositories", fmt.Sprintf("['%s', '%s']", repositories[0], repositories[1])); err != nil {
+		t.Fatal(err)
+	}
+	repositoriesJSON, err := json.Marshal(repositories)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcDir := filepath.Join(dir, "src")
+
+	os.Setenv("GOPKG_SRC_DIR", srcDir)
+	defer os.Unsetenv("GOPKG_SRC_DIR")
+
+	tests := []struct {
+		key    string
+		value  string
+		source string
+	}{
+		{"maintainer.email", "me@example.com", FileSource},
+		{"maintainer.name", "", DefaultSource},
+		{"repositories", string(repositoriesJSON), FileSource},
+		{"src_dir", srcDir, EnvSource},
+		{"proxy", "", DefaultSource},
+	}
+
+	for _, test := range tests {
+		s, err := Get(test.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Value != test.value || s.Source != test.source {
+			t.Errorf("Get(%s) = %s (%s), want %s (%s)", test.key, s.Value, s.Source, test.value, test.source)
+		}
+	}
+
+	if _, err := Get("unknown"); err == nil {
+		t.Error("Get(unknown) should have failed")
+	}
+}
+
+func TestSetInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopkg-config-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "gopkg.yaml")
+	SetPath(path)
+	defer SetPath("")
+
+	tests := map[string]string{
+		"maintainer":       "me",
+		"maintainer.email": "not an email",
+		"bin_dir":          "relative/bin",
+		"repositories":     "[relative]",
+		"proxy":            "localhost:3000",
+		"unknown":          "value",
+	}
+
+	for key, value := range tests {
+		if err := Set(key, value); err == nil {
+			t.Errorf("Set(%s, %s) should have failed", key, value)
+		}
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("configuration file should not have been written")
+	}
+}
+
+func TestPath(t *testing.T) {
+	os.Setenv("GOPKG_CONFIG", "/tmp/env.yaml")
+	defer os.Unsetenv("GOPKG_CONFIG")
+
+	if path, _ := Path(); path != "/tmp/env.yaml" {
+		t.Errorf("Path() = %s, want /tmp/env.yaml", path)
+	}
+
+	SetPath("/tmp/flag.yaml")
+	defer SetPath("")
+
+	if path, _ := Path(); path != "/tmp/flag.yaml" {
+		t.Errorf("Path() = %s, want /tmp/flag.yaml", path)
+	}
+
+	if _, err := Default(); err == nil {
+		t.Error("Default() should fail when the explicit configuration file does not exist")
+	}
+}
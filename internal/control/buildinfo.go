@@ -0,0 +1,42 @@
+package control
+
+// BuildInfoFile is the file (at the root of the control package) recording how the packages were built
+const BuildInfoFile = ".buildinfo"
+
+// BuildInfo record how the packages of a control package were built
+type BuildInfo struct {
+	// The Go import path
+	ImportPath string `yaml:"import_path"`
+	// Version is the control package version
+	Version string `yaml:"version"`
+	// Commit is the upstream commit, if known
+	Commit string `yaml:"commit,omitempty"`
+	// Profile is the build profile used, if any
+	Profile  string `yaml:"profile,omitempty"`
+	Hermetic bool   `yaml:"hermetic,omitempty"`
+	// GoVersion is the go toolchain used (f.e go1.14.4)
+	GoVersion string `yaml:"go_version"`
+	HostOs    string `yaml:"host_os"`
+	HostArch  string `yaml:"host_arch"`
+	// Env are the go environment variables set when building
+	Env map[string]string `yaml:"env,omitempty"`
+	// Dependencies are the installed build dependencies
+	Dependencies []BuildInfoDependency `yaml:"dependencies,omitempty"`
+	// Packages are the sha256 checksums of the produced packages, indexed by file name
+	Packages map[string]string `yaml:"packages,omitempty"`
+	// SourceDate is the date used for reproducible builds (RFC3339)
+	SourceDate string `yaml:"source_date"`
+	// StartedAt & FinishedAt are the build timestamps (RFC3339)
+	// set to the source date so the control package is reproducible
+	StartedAt  string `yaml:"started_at"`
+	FinishedAt string `yaml:"finished_at"`
+}
+
+// BuildInfoDependency is a build dependency used by a build
+type BuildInfoDependency struct {
+	// Name is the source package name
+	Name    string `yaml:"name"`
+	Version string `yaml:"version,omitempty"`
+	// SHA256 is the checksum of the installed files
+	SHA256 string `yaml:"sha256"`
+}
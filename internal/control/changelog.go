@@ -4,15 +4,19 @@ import (
 	"fmt"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
-	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const changelogFile = "changelog.yaml"
 
 // Changelog is the root object containing all package releases
 type Changelog struct {
-	Releases []Release
+	// FormatVersion is the version of the file format, see FormatVersion
+	FormatVersion int `yaml:"format_version,omitempty"`
+	Releases      []Release
 }
 
 // Release is produced each time a package is released
@@ -22,16 +26,110 @@ type Release struct {
 	Version string
 	// Who has taking care of the release upload
 	Uploader string
+	// When the release has been made (RFC1123Z format)
+	Date string `yaml:"date,omitempty"`
+	// Distribution is where the release is uploaded (f.e stable, unstable)
+	Distribution string `yaml:"distribution,omitempty"`
+	// Urgency tells how important upgrading to the release is, see Urgencies
+	Urgency string `yaml:"urgency,omitempty"`
 	// The human descriptions of changes applied since last release
 	Changes []string
 }
 
+// Urgencies are the valid release urgencies
+var Urgencies = []string{"low", "medium", "high", "critical"}
+
+// UpstreamVersion returns the upstream part of the release version
+// f.e 1.2.0 for release 1.2.0-1
+func (r Release) UpstreamVersion() string {
+	if i := strings.LastIndex(r.Version, "-"); i != -1 {
+		return r.Version[:i]
+	}
+	return r.Version
+}
+
+// Time returns the parsed release date
+func (r Release) Time() (time.Time, error) {
+	return time.Parse(time.RFC1123Z, r.Date)
+}
+
+// Revision returns the package revision part of the release version
+// f.e 2 for release 1.2.0-2, 0 if the release has no valid revision
+func (r Release) Revision() int {
+	i := strings.LastIndex(r.Version, "-")
+	if i == -1 {
+		return 0
+	}
+
+	revision, err := strconv.Atoi(r.Version[i+1:])
+	if err != nil {
+		return 0
+	}
+	return revision
+}
+
+// LatestRelease returns the latest release of the changelog
+func (c Changelog) LatestRelease() (Release, error) {
+	if len(c.Releases) == 0 {
+		return Release{}, fmt.Errorf("changelog has no release")
+	}
+
+	return c.Releases[len(c.Releases)-1], nil
+}
+
+// NewRelease append a new release to the changelog and returns it
+// if upstreamVersion is empty the package revision is bumped (f.e 1.2.0-1 -> 1.2.0-2)
+// otherwise the release is the first revision of the new upstream version (f.e 1.3.0-1)
+func (c *Changelog) NewRelease(upstreamVersion, uploader string) (Release, error) {
+	latest, err := c.LatestRelease()
+	if err != nil {
+		return Release{}, err
+	}
+
+	var version string
+	if upstreamVersion == "" {
+		if latest.Revision() == 0 {
+			return Release{}, fmt.Errorf("release %s has no valid revision", latest.Version)
+		}
+		version = fmt.Sprintf("%s-%d", latest.UpstreamVersion(), latest.Revision()+1)
+	} else {
+		upstreamVersion = strings.TrimPrefix(upstreamVersion, "v")
+		if CompareVersions(upstreamVersion, latest.UpstreamVersion()) <= 0 {
+			return Release{}, fmt.Errorf("upstream version %s is not newer than %s", upstreamVersion, latest.UpstreamVersion())
+		}
+		version = fmt.Sprintf("%s-1", upstreamVersion)
+	}
+
+	release := Release{
+		Version:      version,
+		Uploader:     uploader,
+		Date:         time.Now().Format(time.RFC1123Z),
+		Distribution: latest.Distribution,
+		Changes:      []string{},
+	}
+	c.Releases = append(c.Releases, release)
+
+	return release, nil
+}
+
+// AddChange add the given change to the latest release
+func (c *Changelog) AddChange(change string) error {
+	if len(c.Releases) == 0 {
+		return fmt.Errorf("changelog has no release")
+	}
+
+	latest := &c.Releases[len(c.Releases)-1]
+	latest.Changes = append(latest.Changes, change)
+	return nil
+}
+
 // NewChangelog create a brand new changelog
 func newChangelog(initialVersion, uploader string) Changelog {
 	return Changelog{
 		Releases: []Release{{
 			Version:  fmt.Sprintf("%s-1", initialVersion),
 			Uploader: uploader,
+			Date:     time.Now().Format(time.RFC1123Z),
 			Changes:  []string{"Initial release"},
 		}},
 	}
@@ -39,6 +137,7 @@ func newChangelog(initialVersion, uploader string) Changelog {
 
 // WriteChangelog write the given changelog
 func writeChangelog(c Changelog, path string) error {
+	c.FormatVersion = FormatVersion
 	b, err := yaml.Marshal(c)
 	if err != nil {
 		return err
@@ -50,16 +149,76 @@ func writeChangelog(c Changelog, path string) error {
 // ReadChangelog read changelog from file
 func readChangelog(path string) (Changelog, error) {
 	var c Changelog
-
-	f, err := os.Open(filepath.Join(path, changelogFile))
-	if err != nil {
+	if err := decodeFile(filepath.Join(path, changelogFile), &c); err != nil {
 		return Changelog{}, err
 	}
-	defer f.Close()
 
-	if err := yaml.NewDecoder(f).Decode(&c); err != nil {
-		return Changelog{}, err
+	return c, nil
+}
+
+// CompareVersions compare the two given versions using the same ordering as the debian package versions
+// i.e non digit parts are compared lexically, digit parts numerically and ~ sort before everything
+// it returns a negative number if a < b, 0 if a == b and a positive number if a > b
+func CompareVersions(a, b string) int {
+	for a != "" || b != "" {
+		// Compare the non digit prefix
+		var aStr, bStr string
+		aStr, a = splitVersionPart(a, false)
+		bStr, b = splitVersionPart(b, false)
+		if r := compareVersionStrings(aStr, bStr); r != 0 {
+			return r
+		}
+
+		// Then the digit prefix
+		var aNum, bNum string
+		aNum, a = splitVersionPart(a, true)
+		bNum, b = splitVersionPart(b, true)
+		aNum = strings.TrimLeft(aNum, "0")
+		bNum = strings.TrimLeft(bNum, "0")
+		if len(aNum) != len(bNum) {
+			return len(aNum) - len(bNum)
+		}
+		if r := strings.Compare(aNum, bNum); r != 0 {
+			return r
+		}
 	}
 
-	return c, nil
+	return 0
+}
+
+func splitVersionPart(v string, digits bool) (string, string) {
+	i := 0
+	for i < len(v) && (v[i] >= '0' && v[i] <= '9') == digits {
+		i++
+	}
+	return v[:i], v[i:]
+}
+
+func compareVersionStrings(a, b string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var ac, bc int
+		if i < len(a) {
+			ac = versionCharOrder(a[i])
+		}
+		if i < len(b) {
+			bc = versionCharOrder(b[i])
+		}
+		if ac != bc {
+			return ac - bc
+		}
+	}
+	return 0
+}
+
+// versionCharOrder returns the weight of given char
+// ~ sort before the end of the part, letters before non letters
+func versionCharOrder(c byte) int {
+	switch {
+	case c == '~':
+		return -1
+	case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return int(c)
+	default:
+		return int(c) + 256
+	}
 }
@@ -0,0 +1,81 @@
+package control
+
+import "testing"
+
+func TestReleaseUpstreamVersion(t *testing.T) {
+	if v := (Release{Version: "1.2.0-1"}).UpstreamVersion(); v != "1.2.0" {
+		t.Errorf("wrong upstream version (%s)", v)
+	}
+
+	if v := (Release{Version: "1.2.0-rc1-3"}).UpstreamVersion(); v != "1.2.0-rc1" {
+		t.Errorf("wrong upstream version (%s)", v)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		A        string
+		B        string
+		Expected int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0.0", "1.0.1", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"1.0.0-2", "1.0.0-10", -1},
+		{"0.0~git202010152005", "0.0", -1},
+		{"0.0~git202010152005", "0.0~git202101010000", -1},
+		{"1.0.0~rc1", "1.0.0", -1},
+		{"1.0.0a", "1.0.0", 1},
+		{"1.0.01", "1.0.1", 0},
+	}
+
+	for _, test := range tests {
+		r := CompareVersions(test.A, test.B)
+		if (r < 0 && test.Expected >= 0) || (r > 0 && test.Expected <= 0) || (r == 0 && test.Expected != 0) {
+			t.Errorf("wrong comparison of %s and %s (got: %d want: %d)", test.A, test.B, r, test.Expected)
+		}
+	}
+}
+
+func TestRelease_Revision(t *testing.T) {
+	if r := (Release{Version: "1.2.0-3"}).Revision(); r != 3 {
+		t.Errorf("wrong revision (%d)", r)
+	}
+	if r := (Release{Version: "1.2.0"}).Revision(); r != 0 {
+		t.Errorf("wrong revision (%d)", r)
+	}
+}
+
+func TestChangelog_NewRelease(t *testing.T) {
+	c := Changelog{Releases: []Release{{Version: "1.2.0-1", Distribution: "unstable"}}}
+
+	r, err := c.NewRelease("", "Aloïs Micard <alois@micard.lu>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != "1.2.0-2" || r.Distribution != "unstable" || r.Uploader != "Aloïs Micard <alois@micard.lu>" {
+		t.Errorf("wrong release (%+v)", r)
+	}
+
+	if r, err := c.NewRelease("v1.3.0", ""); err != nil || r.Version != "1.3.0-1" {
+		t.Errorf("wrong release (%+v, %v)", r, err)
+	}
+	if _, err := c.NewRelease("1.2.5", ""); err == nil {
+		t.Error("NewRelease should have failed")
+	}
+
+	if len(c.Releases) != 3 {
+		t.Fatalf("wrong number of releases (%d)", len(c.Releases))
+	}
+
+	if err := c.AddChange("Fix build"); err != nil {
+		t.Error(err)
+	}
+	if latest, _ := c.LatestRelease(); len(latest.Changes) != 1 || latest.Changes[0] != "Fix build" {
+		t.Errorf("wrong changes (%v)", latest.Changes)
+	}
+
+	if _, err := (&Changelog{}).NewRelease("", ""); err == nil {
+		t.Error("NewRelease should have failed")
+	}
+}
@@ -55,3 +55,18 @@ func ReadCtrlDirectory(path string) (Metadata, Changelog, error) {
 
 	return m, c, nil
 }
+
+// UpdateCtrlDirectory overwrite the metadata & changelog of the existing control directory at given path
+func UpdateCtrlDirectory(path string, metadata Metadata, changelog Changelog) error {
+	rootDir := filepath.Join(path, GoPkgDir)
+
+	if _, err := os.Stat(rootDir); err != nil {
+		return err
+	}
+
+	if err := writeMetadata(metadata, rootDir); err != nil {
+		return err
+	}
+
+	return writeChangelog(changelog, rootDir)
+}
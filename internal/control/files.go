@@ -0,0 +1,99 @@
+package control
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Sections are the supported extra file sections
+var Sections = []string{"man", "completions/bash", "completions/zsh", "completions/fish", "doc", "etc"}
+
+// File is an extra file shipped in the binary packages (man page, shell completion...)
+// the file is either copied from the upstream sources or generated by running the binary
+type File struct {
+	// Section is one of man, completions/bash, completions/zsh, completions/fish, doc or etc
+	Section string
+	// Source is the upstream file path (relative to the control directory)
+	Source string `yaml:"source,omitempty"`
+	// Command are the arguments passed to the binary to generate the file (f.e [completion, bash])
+	// the binary standard output is used as file content
+	Command []string `yaml:"command,omitempty"`
+	// Name is the installed file name
+	// default to the source name, or to the conventional completion file name
+	Name string `yaml:"name,omitempty"`
+	// Binary is the name of the binary the file belongs to, when the package has several
+	// default to the first binary
+	Binary string `yaml:"binary,omitempty"`
+}
+
+// Validate make sure the file is well defined
+func (f File) Validate() error {
+	valid := false
+	for _, section := range Sections {
+		if f.Section == section {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid section %s (supported: %v)", f.Section, Sections)
+	}
+
+	if (f.Source == "") == (len(f.Command) == 0) {
+		return fmt.Errorf("%s file must have either a source or a command", f.Section)
+	}
+
+	if f.Source != "" && (path.IsAbs(f.Source) || strings.HasPrefix(path.Clean(f.Source), "..")) {
+		return fmt.Errorf("%s file source must be relative to the control directory: %s", f.Section, f.Source)
+	}
+
+	if strings.Contains(f.Name, "/") {
+		return fmt.Errorf("%s file name must not contain a path: %s", f.Section, f.Name)
+	}
+
+	if f.Section == "man" && path.Ext(f.FileName("")) == "" {
+		return fmt.Errorf("man page %s has no section extension (f.e foo.1)", f.FileName(""))
+	}
+
+	return nil
+}
+
+// FileName returns the installed file name for the binary with given name
+func (f File) FileName(binName string) string {
+	if f.Name != "" {
+		return f.Name
+	}
+	if f.Source != "" {
+		return path.Base(f.Source)
+	}
+
+	switch f.Section {
+	case "completions/bash":
+		return binName
+	case "completions/zsh":
+		return "_" + binName
+	case "completions/fish":
+		return binName + ".fish"
+	default:
+		return binName
+	}
+}
+
+// ArchivePath returns the path of the file in the binary package of the binary with given name
+// man pages are placed in their section directory (f.e share/man/man1/foo.1),
+// docs & configuration files in a directory named after the binary (f.e share/doc/foo/README.md)
+func (f File) ArchivePath(binName string) string {
+	name := f.FileName(binName)
+
+	switch f.Section {
+	case "man":
+		return path.Join("share", "man", "man"+strings.TrimPrefix(path.Ext(name), "."), name)
+	case "doc":
+		return path.Join("share", "doc", binName, name)
+	case "etc":
+		return path.Join("etc", binName, name)
+	default:
+		return path.Join("share", f.Section, name)
+	}
+}
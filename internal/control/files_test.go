@@ -0,0 +1,45 @@
+package control
+
+import "testing"
+
+func TestFile_ArchivePath(t *testing.T) {
+	tests := []struct {
+		File     File
+		Expected string
+	}{
+		{File{Section: "man", Source: "docs/foo.1"}, "share/man/man1/foo.1"},
+		{File{Section: "man", Command: []string{"man"}, Name: "foo.8"}, "share/man/man8/foo.8"},
+		{File{Section: "completions/bash", Command: []string{"completion", "bash"}}, "share/completions/bash/foo"},
+		{File{Section: "completions/zsh", Command: []string{"completion", "zsh"}}, "share/completions/zsh/_foo"},
+		{File{Section: "completions/fish", Command: []string{"completion", "fish"}}, "share/completions/fish/foo.fish"},
+		{File{Section: "doc", Source: "README.md"}, "share/doc/foo/README.md"},
+		{File{Section: "etc", Source: "config/default.yaml", Name: "config.yaml"}, "etc/foo/config.yaml"},
+	}
+
+	for _, test := range tests {
+		if err := test.File.Validate(); err != nil {
+			t.Error(err)
+		}
+		if p := test.File.ArchivePath("foo"); p != test.Expected {
+			t.Errorf("wrong archive path (got: %s want: %s)", p, test.Expected)
+		}
+	}
+}
+
+func TestFile_Validate(t *testing.T) {
+	files := []File{
+		{Section: "info", Source: "foo.info"},
+		{Section: "doc"},
+		{Section: "doc", Source: "README.md", Command: []string{"help"}},
+		{Section: "doc", Source: "../README.md"},
+		{Section: "doc", Source: "/etc/passwd"},
+		{Section: "doc", Source: "README.md", Name: "docs/README.md"},
+		{Section: "man", Command: []string{"man"}},
+	}
+
+	for _, f := range files {
+		if err := f.Validate(); err == nil {
+			t.Errorf("Validate should have failed for %+v", f)
+		}
+	}
+}
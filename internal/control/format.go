@@ -0,0 +1,57 @@
+package control
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FormatVersion is the current format version of the control files
+// control files without version are considered to use the version 1
+//
+// 1: initial format
+// 2: binaries, files & relations of the packages, distribution & urgency of the releases
+const FormatVersion = 2
+
+// decodeFile strictly decode the control file at given path into v
+// the format version is checked first so files written by a newer gopkg are reported as such,
+// then unknown fields are reported with their line number
+func decodeFile(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var header struct {
+		FormatVersion int `yaml:"format_version"`
+	}
+	if err := yaml.Unmarshal(b, &header); err != nil {
+		return fmt.Errorf("invalid %s: %s", filepath.Base(path), err)
+	}
+	if err := checkFormatVersion(filepath.Base(path), header.FormatVersion); err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.SetStrict(true)
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid %s: %s", filepath.Base(path), err)
+	}
+
+	return nil
+}
+
+// checkFormatVersion make sure the format version of given control file is supported
+func checkFormatVersion(fileName string, version int) error {
+	if version < 0 {
+		return fmt.Errorf("invalid %s: invalid format version %d", fileName, version)
+	}
+	if version > FormatVersion {
+		return fmt.Errorf("%s format version %d is not supported (max: %d), please upgrade gopkg", fileName, version, FormatVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package control
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadCtrlDirectoryStrict(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := CreateCtrlDirectory(tmpDir, "1.0.0", "Aloïs Micard <alois@micard.lu>", Metadata{ImportPath: "github.com/creekorful/foo"}); err != nil {
+		t.Error(err)
+	}
+
+	m, c, err := ReadCtrlDirectory(tmpDir)
+	if err != nil {
+		t.Error(err)
+	}
+	if m.FormatVersion != FormatVersion || c.FormatVersion != FormatVersion {
+		t.Errorf("wrong format versions (metadata: %d changelog: %d)", m.FormatVersion, c.FormatVersion)
+	}
+
+	metadataPath := filepath.Join(tmpDir, GoPkgDir, metadataFile)
+	tests := map[string]string{
+		// Files without format version are still supported
+		"importpath: github.com/creekorful/foo\n":                                           "",
+		"importpath: github.com/creekorful/foo\nbuild_dependancies: []\n":                   "line 2: field build_dependancies not found",
+		"importpath: github.com/creekorful/foo\npackages:\n- alias: foo\n  bin_name: foo\n": "line 4: field bin_name not found",
+		"format_version: 99\nimportpath: github.com/creekorful/foo\n":                       "metadata.yaml format version 99 is not supported",
+		// Newer files are reported as such rather than having unknown fields
+		"format_version: 99\nimportpath: github.com/creekorful/foo\nnew_field: true\n": "please upgrade gopkg",
+	}
+
+	for content, expected := range tests {
+		if err := ioutil.WriteFile(metadataPath, []byte(content), 0640); err != nil {
+			t.Error(err)
+		}
+
+		_, _, err := ReadCtrlDirectory(tmpDir)
+		if expected == "" {
+			if err != nil {
+				t.Errorf("unexpected error for %q (%s)", content, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("wrong error for %q (got: %v want: %s)", content, err, expected)
+		}
+	}
+}
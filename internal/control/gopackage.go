@@ -0,0 +1,49 @@
+package control
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ImportPackage parse the Go package at given path, which may also be a file of the package (f.e main.go)
+// the package is looked up for the host then for each given target (os/arches),
+// so packages building only on another OS (f.e windows) are found too
+func ImportPackage(path string, targets map[string][]string) (*build.Package, error) {
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+
+	p, err := build.ImportDir(path, 0)
+	if err == nil {
+		return p, nil
+	}
+
+	for _, t := range sortedTargets(targets) {
+		ctx := build.Default
+		ctx.GOOS, ctx.GOARCH = t.Os, t.Arch
+		if p, targetErr := ctx.ImportDir(path, 0); targetErr == nil {
+			return p, nil
+		}
+	}
+
+	return nil, err
+}
+
+// sortedTargets returns the os/arch of given targets (without variant), sorted so the lookup is deterministic
+func sortedTargets(targets map[string][]string) []Target {
+	var result []Target
+	for targetOs, targetArches := range targets {
+		for _, targetArch := range targetArches {
+			result = append(result, Target{Os: targetOs, Arch: strings.SplitN(targetArch, "/", 2)[0]})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Os+"/"+result[i].Arch < result[j].Os+"/"+result[j].Arch
+	})
+
+	return result
+}
@@ -0,0 +1,55 @@
+package control
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-pkg-org/gopkg/internal/util"
+)
+
+// HooksDir is the directory (in the control directory & binary packages) holding the hook scripts
+const HooksDir = "hooks"
+
+const (
+	// PreInstallHook is run before the package files are installed, a failure abort the install
+	PreInstallHook = "pre-install"
+	// PostInstallHook is run once the package files are installed, a failure rollback the install
+	PostInstallHook = "post-install"
+	// PreRemoveHook is run before the package files are removed, a failure abort the removal
+	PreRemoveHook = "pre-remove"
+	// PostRemoveHook is run once the package files are removed, a failure is only reported
+	PostRemoveHook = "post-remove"
+)
+
+// Hooks are the supported hook names
+var Hooks = []string{PreInstallHook, PostInstallHook, PreRemoveHook, PostRemoveHook}
+
+// ReadHooks returns the hook scripts of the control directory at given path, indexed by name
+func ReadHooks(path string) (map[string][]byte, error) {
+	dir := filepath.Join(path, GoPkgDir, HooksDir)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string][]byte{}, nil
+		}
+		return nil, err
+	}
+
+	hooks := map[string][]byte{}
+	for _, file := range files {
+		if file.IsDir() || !util.Contains(Hooks, file.Name()) {
+			return nil, fmt.Errorf("invalid hook %s (supported: %v)", file.Name(), Hooks)
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		hooks[file.Name()] = b
+	}
+
+	return hooks, nil
+}
@@ -0,0 +1,43 @@
+package control
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadHooks(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if hooks, err := ReadHooks(tmpDir); err != nil || len(hooks) != 0 {
+		t.Errorf("wrong hooks (%v, %v)", hooks, err)
+	}
+
+	dir := filepath.Join(tmpDir, GoPkgDir, HooksDir)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		t.Error(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, PostInstallHook), []byte("#!/bin/sh"), 0750); err != nil {
+		t.Error(err)
+	}
+
+	hooks, err := ReadHooks(tmpDir)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(hooks) != 1 || string(hooks[PostInstallHook]) != "#!/bin/sh" {
+		t.Errorf("wrong hooks (%v)", hooks)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "post-upgrade"), []byte("#!/bin/sh"), 0750); err != nil {
+		t.Error(err)
+	}
+	if _, err := ReadHooks(tmpDir); err == nil {
+		t.Error("ReadHooks should have failed")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
-	"os"
 	"path/filepath"
 )
 
@@ -12,8 +11,16 @@ const metadataFile = "metadata.yaml"
 
 // Metadata represent the package metadata
 type Metadata struct {
+	// FormatVersion is the version of the file format, see FormatVersion
+	FormatVersion int `yaml:"format_version,omitempty"`
 	// The Go import path
 	ImportPath string
+	// Human description of the upstream project
+	Description string `yaml:"description,omitempty"`
+	// The upstream project homepage
+	Homepage string `yaml:"homepage,omitempty"`
+	// The upstream license, as a SPDX expression (f.e MIT or Apache-2.0 AND MIT)
+	License string `yaml:"license,omitempty"`
 	// List of the package maintainers
 	// i.e who take the responsibility for uploading & managing it
 	Maintainers []string
@@ -21,24 +28,109 @@ type Metadata struct {
 	BuildDependencies []string `yaml:"build_dependencies"`
 	// List of the packages built by this control package
 	Packages []Package
+	// Profiles are the named build profiles (f.e release, debug)
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+	// Tests configure the test stage of the build
+	Tests Tests `yaml:"tests,omitempty"`
+	// Lint configure the control directory lint
+	Lint Lint `yaml:"lint,omitempty"`
+}
+
+// Lint configure how the control directory is linted
+type Lint struct {
+	// Ignore are the suppressed lint rules (f.e missing-license)
+	Ignore []string `yaml:"ignore,omitempty"`
+}
+
+// Tests configure how the upstream tests are run when building
+type Tests struct {
+	// Skip disable the tests
+	Skip bool `yaml:"skip,omitempty"`
+	// Exclude are the packages not tested (f.e github.com/foo/bar/integration or github.com/foo/bar/e2e/...)
+	Exclude []string `yaml:"exclude,omitempty"`
+	// Flags are the extra flags passed to go test (f.e -short)
+	Flags []string `yaml:"flags,omitempty"`
 }
 
 // Package represent a package installable
 type Package struct {
 	// The package alias (i.e what the user will use to identify the package)
 	Alias string
-	// Main is the full path to the file containing the `func main()` sequence
+	// Main is the relative path to the main package directory (f.e ./cmd/foo)
 	Main string `yaml:"main,omitempty"`
 	// BinName is the name of the binary that will be installed
-	BinName string
+	BinName string `yaml:"binname,omitempty"`
+	// Binaries are the binaries of the package, when it has several (replace Main & BinName)
+	Binaries []Binary `yaml:"binaries,omitempty"`
 	// Human description of the package
 	Description string
 	// Targets describe the build target (os,arches)
+	// arch may have a variant: f.e arm/7 (GOARM=7) or mips/softfloat (GOMIPS=softfloat)
 	Targets map[string][]string `yaml:"targets,omitempty"`
+	// ExcludeTargets are the targets not built (f.e windows/arm or darwin)
+	ExcludeTargets []string `yaml:"exclude_targets,omitempty"`
+	// TargetOverrides are the build settings specific to some targets
+	// indexed by os, os/arch or os/arch/variant
+	TargetOverrides map[string]TargetOverride `yaml:"target_overrides,omitempty"`
+	// LDFlags are the flags passed to the linker (f.e -X main.version={{.Version}})
+	// available variables are Version, Release, Commit, Date, Os and Arch
+	LDFlags string `yaml:"ldflags,omitempty"`
+	// Tags are the build tags used when building the binary
+	Tags []string `yaml:"tags,omitempty"`
+	// Cgo enable or disable cgo (default to the go tool behavior)
+	Cgo *bool `yaml:"cgo,omitempty"`
+	// Env are the extra environment variables used when building the binary
+	// values may use the same variables as LDFlags
+	Env map[string]string `yaml:"env,omitempty"`
+	// Depends are the packages required at runtime, f.e protoc (>= 3.0)
+	Depends []string `yaml:"depends,omitempty"`
+	// Recommends are the packages that should be installed alongside
+	Recommends []string `yaml:"recommends,omitempty"`
+	// Provides are the virtual names provided by the package, f.e protoc-gen (= 1.0)
+	Provides []string `yaml:"provides,omitempty"`
+	// Conflicts are the packages which cannot be installed alongside
+	Conflicts []string `yaml:"conflicts,omitempty"`
+	// Files are the extra files shipped with the binary (man pages, shell completions, docs, configuration)
+	Files []File `yaml:"files,omitempty"`
+}
+
+// Binary is a binary of a package
+type Binary struct {
+	// Main is the relative path to the main package directory (f.e ./cmd/foo)
+	Main string `yaml:"main,omitempty"`
+	// BinName is the name of the binary that will be installed
+	BinName string `yaml:"binname"`
+}
+
+// GetBinaries returns the binaries of the package
+func (p Package) GetBinaries() []Binary {
+	if len(p.Binaries) > 0 {
+		return p.Binaries
+	}
+
+	return []Binary{{Main: p.Main, BinName: p.BinName}}
+}
+
+// GetBinary returns the binary of the package with given name
+// the first binary is returned if name is empty
+func (p Package) GetBinary(name string) (Binary, error) {
+	binaries := p.GetBinaries()
+	if name == "" {
+		return binaries[0], nil
+	}
+
+	for _, binary := range binaries {
+		if binary.BinName == name {
+			return binary, nil
+		}
+	}
+
+	return Binary{}, fmt.Errorf("package %s has no binary %s", p.Alias, name)
 }
 
 // writeMetadata write the given metadata
 func writeMetadata(m Metadata, path string) error {
+	m.FormatVersion = FormatVersion
 	b, err := yaml.Marshal(m)
 	if err != nil {
 		return err
@@ -50,14 +142,7 @@ func writeMetadata(m Metadata, path string) error {
 // ReadMetadata read metadata from file
 func readMetadata(path string) (Metadata, error) {
 	var m Metadata
-
-	f, err := os.Open(filepath.Join(path, metadataFile))
-	if err != nil {
-		return Metadata{}, err
-	}
-	defer f.Close()
-
-	if err := yaml.NewDecoder(f).Decode(&m); err != nil {
+	if err := decodeFile(filepath.Join(path, metadataFile), &m); err != nil {
 		return Metadata{}, err
 	}
 
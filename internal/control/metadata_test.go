@@ -0,0 +1,21 @@
+package control
+
+import "testing"
+
+func TestPackage_GetBinaries(t *testing.T) {
+	p := Package{Alias: "foo", Main: "./cmd/foo", BinName: "foo"}
+	if binaries := p.GetBinaries(); len(binaries) != 1 || binaries[0] != (Binary{Main: "./cmd/foo", BinName: "foo"}) {
+		t.Errorf("wrong binaries (%+v)", binaries)
+	}
+
+	p = Package{Alias: "foo-extras", Binaries: []Binary{{Main: "./cmd/bar", BinName: "bar"}, {Main: "./cmd/baz", BinName: "baz"}}}
+	if binary, err := p.GetBinary(""); err != nil || binary.BinName != "bar" {
+		t.Errorf("wrong default binary (%+v)", binary)
+	}
+	if binary, err := p.GetBinary("baz"); err != nil || binary.Main != "./cmd/baz" {
+		t.Errorf("wrong binary (%+v)", binary)
+	}
+	if _, err := p.GetBinary("qux"); err == nil {
+		t.Error("GetBinary should have failed")
+	}
+}
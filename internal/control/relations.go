@@ -0,0 +1,135 @@
+package control
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RelationsFile is the file (at the root of the binary packages) holding the package relations
+const RelationsFile = "relations.yaml"
+
+// operators are the supported version constraint operators
+var operators = []string{">=", "<=", "=", ">", "<"}
+
+// dependencyRegex match a dependency: name [(operator version)]
+var dependencyRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9./_+-]*)\s*(?:\(\s*([<>=]+)\s*([^\s()<>=]+)\s*\))?$`)
+
+// Relations are the runtime relationships of a binary package with the other packages
+// every entry is a package name (or provided name) with an optional version constraint, f.e protoc (>= 3.0)
+type Relations struct {
+	// Depends are the packages required to run the package
+	Depends []string `yaml:"depends,omitempty" json:"depends,omitempty"`
+	// Recommends are the packages that should be installed alongside the package
+	Recommends []string `yaml:"recommends,omitempty" json:"recommends,omitempty"`
+	// Provides are the virtual names provided by the package, with an optional exact version, f.e protoc-gen (= 1.0)
+	Provides []string `yaml:"provides,omitempty" json:"provides,omitempty"`
+	// Conflicts are the packages which cannot be installed alongside the package
+	Conflicts []string `yaml:"conflicts,omitempty" json:"conflicts,omitempty"`
+}
+
+// Dependency is a package name with an optional version constraint
+type Dependency struct {
+	Name string
+	// Operator is one of >=, <=, =, > or < (empty if no constraint)
+	Operator string
+	Version  string
+}
+
+// ParseDependency parse given dependency, f.e protoc or protoc (>= 3.0)
+func ParseDependency(s string) (Dependency, error) {
+	match := dependencyRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return Dependency{}, fmt.Errorf("invalid dependency: %s", s)
+	}
+
+	d := Dependency{Name: match[1], Operator: match[2], Version: match[3]}
+	if d.Operator != "" {
+		valid := false
+		for _, operator := range operators {
+			if d.Operator == operator {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return Dependency{}, fmt.Errorf("invalid operator %s in dependency: %s", d.Operator, s)
+		}
+	}
+
+	return d, nil
+}
+
+// String returns the dependency in its textual form
+func (d Dependency) String() string {
+	if d.Operator == "" {
+		return d.Name
+	}
+	return fmt.Sprintf("%s (%s %s)", d.Name, d.Operator, d.Version)
+}
+
+// Match returns true if the package with given name & version satisfy the dependency
+// if the constraint has no package revision only the upstream version is compared (f.e 1.2.0 match 1.2.0-3)
+// a package without version only satisfy dependencies without constraint
+func (d Dependency) Match(name, version string) bool {
+	if name != d.Name {
+		return false
+	}
+	if d.Operator == "" {
+		return true
+	}
+	if version == "" {
+		return false
+	}
+
+	if !strings.Contains(d.Version, "-") {
+		version = Release{Version: version}.UpstreamVersion()
+	}
+
+	r := CompareVersions(version, d.Version)
+	switch d.Operator {
+	case ">=":
+		return r >= 0
+	case "<=":
+		return r <= 0
+	case ">":
+		return r > 0
+	case "<":
+		return r < 0
+	default:
+		return r == 0
+	}
+}
+
+// Validate make sure the relations are well formed
+func (r Relations) Validate() error {
+	for _, deps := range [][]string{r.Depends, r.Recommends, r.Conflicts} {
+		for _, dep := range deps {
+			if _, err := ParseDependency(dep); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, provide := range r.Provides {
+		d, err := ParseDependency(provide)
+		if err != nil {
+			return err
+		}
+		if d.Operator != "" && d.Operator != "=" {
+			return fmt.Errorf("provided version must be exact: %s", provide)
+		}
+	}
+
+	return nil
+}
+
+// Relations returns the relations of the package
+func (p Package) Relations() Relations {
+	return Relations{
+		Depends:    p.Depends,
+		Recommends: p.Recommends,
+		Provides:   p.Provides,
+		Conflicts:  p.Conflicts,
+	}
+}
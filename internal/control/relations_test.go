@@ -0,0 +1,79 @@
+package control
+
+import "testing"
+
+func TestParseDependency(t *testing.T) {
+	tests := []struct {
+		Dependency string
+		Expected   Dependency
+	}{
+		{"protoc", Dependency{Name: "protoc"}},
+		{"github.com/golang/protobuf/protoc-gen-go", Dependency{Name: "github.com/golang/protobuf/protoc-gen-go"}},
+		{"protoc (>= 3.0)", Dependency{Name: "protoc", Operator: ">=", Version: "3.0"}},
+		{" protoc-gen-go(=1.2.0-1) ", Dependency{Name: "protoc-gen-go", Operator: "=", Version: "1.2.0-1"}},
+	}
+
+	for _, test := range tests {
+		d, err := ParseDependency(test.Dependency)
+		if err != nil {
+			t.Error(err)
+		}
+		if d != test.Expected {
+			t.Errorf("wrong dependency (got: %+v want: %+v)", d, test.Expected)
+		}
+	}
+
+	for _, dep := range []string{"", "protoc (>= )", "protoc (=> 3.0)", "protoc 3.0", "(>= 3.0)"} {
+		if _, err := ParseDependency(dep); err == nil {
+			t.Errorf("ParseDependency should have failed for %s", dep)
+		}
+	}
+
+	if s := (Dependency{Name: "protoc", Operator: "<", Version: "4"}).String(); s != "protoc (< 4)" {
+		t.Errorf("wrong dependency string (%s)", s)
+	}
+}
+
+func TestDependency_Match(t *testing.T) {
+	tests := []struct {
+		Dependency string
+		Name       string
+		Version    string
+		Expected   bool
+	}{
+		{"protoc", "protoc", "", true},
+		{"protoc", "protobuf", "3.0.0-1", false},
+		{"protoc (>= 3.0)", "protoc", "3.12.0-1", true},
+		{"protoc (>= 3.0)", "protoc", "2.6.1-1", false},
+		{"protoc (>= 3.0)", "protoc", "", false},
+		{"protoc (< 3.0)", "protoc", "2.6.1-1", true},
+		{"protoc (= 3.0.0)", "protoc", "3.0.0-2", true},
+		{"protoc (= 3.0.0-1)", "protoc", "3.0.0-2", false},
+		{"protoc (> 3.0.0-1)", "protoc", "3.0.0-2", true},
+		{"protoc (<= 3.0.0)", "protoc", "3.0.0-2", true},
+	}
+
+	for _, test := range tests {
+		d, err := ParseDependency(test.Dependency)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Match(test.Name, test.Version) != test.Expected {
+			t.Errorf("wrong match of %s by %s %s", test.Dependency, test.Name, test.Version)
+		}
+	}
+}
+
+func TestRelations_Validate(t *testing.T) {
+	r := Relations{Depends: []string{"protoc (>= 3.0)"}, Provides: []string{"protoc-gen (= 1.0)"}}
+	if err := r.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	if err := (Relations{Provides: []string{"protoc-gen (>= 1.0)"}}).Validate(); err == nil {
+		t.Error("Validate should have failed")
+	}
+	if err := (Relations{Conflicts: []string{"protoc ("}}).Validate(); err == nil {
+		t.Error("Validate should have failed")
+	}
+}
@@ -0,0 +1,110 @@
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const jsonSchemaVersion = "http://json-schema.org/draft-07/schema#"
+
+// MetadataSchema returns the JSON schema of the metadata file
+func MetadataSchema() ([]byte, error) {
+	return schema("gopkg control metadata", reflect.TypeOf(Metadata{}))
+}
+
+// ChangelogSchema returns the JSON schema of the changelog file
+func ChangelogSchema() ([]byte, error) {
+	return schema("gopkg control changelog", reflect.TypeOf(Changelog{}))
+}
+
+// schema returns the JSON schema of given type
+// the schema is generated using the yaml names of the struct fields
+func schema(title string, t reflect.Type) ([]byte, error) {
+	s, err := typeSchema(t)
+	if err != nil {
+		return nil, err
+	}
+
+	s["$schema"] = jsonSchemaVersion
+	s["title"] = title
+
+	// Only the supported format versions are valid
+	if properties, ok := s["properties"].(map[string]interface{}); ok {
+		if formatVersion, ok := properties["format_version"].(map[string]interface{}); ok {
+			formatVersion["minimum"] = 1
+			formatVersion["maximum"] = FormatVersion
+		}
+	}
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+func typeSchema(t reflect.Type) (map[string]interface{}, error) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type: %s", t.Key())
+		}
+		values, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			name := yamlName(field)
+			if name == "-" {
+				continue
+			}
+
+			property, err := typeSchema(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			properties[name] = property
+		}
+		return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type: %s", t)
+	}
+}
+
+// yamlName returns the name of given field as encoded by yaml
+// i.e the tag name or the lowercased field name
+func yamlName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("yaml"), ",")[0]; name != "" {
+		return name
+	}
+
+	return strings.ToLower(field.Name)
+}
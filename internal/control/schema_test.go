@@ -0,0 +1,63 @@
+package control
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	b, err := MetadataSchema()
+	if err != nil {
+		t.Error(err)
+	}
+
+	var s struct {
+		AdditionalProperties bool `json:"additionalProperties"`
+		Properties           map[string]struct {
+			Type  string `json:"type"`
+			Items struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"items"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Error(err)
+	}
+
+	if s.AdditionalProperties {
+		t.Error("unknown fields should not be allowed")
+	}
+	if s.Properties["build_dependencies"].Type != "array" || s.Properties["importpath"].Type != "string" {
+		t.Errorf("wrong properties (%+v)", s.Properties)
+	}
+	if _, exist := s.Properties["packages"].Items.Properties["binname"]; !exist {
+		t.Error("missing package binname property")
+	}
+}
+
+// Make sure the published schemas are up-to-date
+// they can be regenerated using `gopkg schema metadata|changelog`
+func TestPublishedSchemas(t *testing.T) {
+	schemas := map[string]func() ([]byte, error){
+		"metadata.schema.json":  MetadataSchema,
+		"changelog.schema.json": ChangelogSchema,
+	}
+
+	for file, generate := range schemas {
+		expected, err := generate()
+		if err != nil {
+			t.Error(err)
+		}
+
+		published, err := ioutil.ReadFile(filepath.Join("..", "..", "schema", file))
+		if err != nil {
+			t.Error(err)
+		}
+
+		if string(published) != string(expected) {
+			t.Errorf("schema/%s is outdated, regenerate it using `gopkg schema`", file)
+		}
+	}
+}
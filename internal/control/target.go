@@ -0,0 +1,159 @@
+package control
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// variantEnvs are the environment variables used to select the arch variant
+// only the variables supported by go 1.14 are listed (GOAMD64, GOARM64... came later)
+var variantEnvs = map[string]string{
+	"386":      "GO386",
+	"arm":      "GOARM",
+	"mips":     "GOMIPS",
+	"mipsle":   "GOMIPS",
+	"mips64":   "GOMIPS64",
+	"mips64le": "GOMIPS64",
+	"wasm":     "GOWASM",
+}
+
+// Profile is a named set of build settings (f.e release or debug)
+// selected when building
+type Profile struct {
+	// Targets override the packages targets if set
+	Targets map[string][]string `yaml:"targets,omitempty"`
+	// ExcludeTargets are the targets not built, see Package.ExcludeTargets
+	ExcludeTargets []string `yaml:"exclude_targets,omitempty"`
+	// LDFlags are appended to the packages ldflags
+	LDFlags string `yaml:"ldflags,omitempty"`
+	// Tags are appended to the packages tags
+	Tags []string `yaml:"tags,omitempty"`
+	// Env are merged with the packages env
+	Env map[string]string `yaml:"env,omitempty"`
+}
+
+// TargetOverride are the build settings specific to some targets
+type TargetOverride struct {
+	// Tags are appended to the package tags
+	Tags []string `yaml:"tags,omitempty"`
+	// Env are merged with the package env
+	Env map[string]string `yaml:"env,omitempty"`
+}
+
+// Target is a resolved build target
+type Target struct {
+	Os   string
+	Arch string
+	// Variant is the arch variant (f.e 7 for GOARM=7, softfloat for GOMIPS=softfloat)
+	Variant string
+	// Tags are the target specific build tags
+	Tags []string
+	// Env are the target specific environment variables
+	Env map[string]string
+}
+
+// Name returns the target name: os/arch[/variant]
+func (t Target) Name() string {
+	if t.Variant != "" {
+		return fmt.Sprintf("%s/%s/%s", t.Os, t.Arch, t.Variant)
+	}
+	return fmt.Sprintf("%s/%s", t.Os, t.Arch)
+}
+
+// PkgArch returns the arch used in the binary package name: arch[-variant]
+func (t Target) PkgArch() string {
+	if t.Variant != "" {
+		return fmt.Sprintf("%s-%s", t.Arch, t.Variant)
+	}
+	return t.Arch
+}
+
+// VariantEnv returns the environment variable selecting the target variant
+// or an empty string if the target has no variant
+func (t Target) VariantEnv() string {
+	if t.Variant == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s=%s", variantEnvs[t.Arch], t.Variant)
+}
+
+// matches returns true if the target matches given pattern: os, os/arch or os/arch/variant
+func (t Target) matches(pattern string) bool {
+	parts := strings.Split(pattern, "/")
+	if parts[0] != t.Os {
+		return false
+	}
+	if len(parts) > 1 && parts[1] != t.Arch {
+		return false
+	}
+	if len(parts) > 2 && parts[2] != t.Variant {
+		return false
+	}
+	return true
+}
+
+// GetTargets resolve the package targets using given profile (may be nil)
+// exclusions & overrides are applied, and targets are sorted by name
+func (p Package) GetTargets(profile *Profile) ([]Target, error) {
+	targetSpecs := p.Targets
+	excludes := p.ExcludeTargets
+	if profile != nil {
+		if len(profile.Targets) > 0 {
+			targetSpecs = profile.Targets
+		}
+		excludes = append(append([]string{}, excludes...), profile.ExcludeTargets...)
+	}
+
+	var targets []Target
+	for targetOs, targetArches := range targetSpecs {
+		for _, targetArch := range targetArches {
+			t := Target{Os: targetOs, Arch: targetArch}
+			if parts := strings.SplitN(targetArch, "/", 2); len(parts) == 2 {
+				t.Arch, t.Variant = parts[0], parts[1]
+				if _, exist := variantEnvs[t.Arch]; !exist {
+					return nil, fmt.Errorf("arch %s does not support variants", t.Arch)
+				}
+			}
+
+			excluded := false
+			for _, exclude := range excludes {
+				if t.matches(exclude) {
+					excluded = true
+					break
+				}
+			}
+			if excluded {
+				continue
+			}
+
+			// Apply overrides, from the less specific to the most specific
+			var patterns []string
+			for pattern := range p.TargetOverrides {
+				if t.matches(pattern) {
+					patterns = append(patterns, pattern)
+				}
+			}
+			sort.Slice(patterns, func(i, j int) bool {
+				return strings.Count(patterns[i], "/") < strings.Count(patterns[j], "/")
+			})
+
+			t.Env = map[string]string{}
+			for _, pattern := range patterns {
+				override := p.TargetOverrides[pattern]
+				t.Tags = append(t.Tags, override.Tags...)
+				for key, value := range override.Env {
+					t.Env[key] = value
+				}
+			}
+
+			targets = append(targets, t)
+		}
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Name() < targets[j].Name()
+	})
+
+	return targets, nil
+}
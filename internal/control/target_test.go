@@ -0,0 +1,61 @@
+package control
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPackageGetTargets(t *testing.T) {
+	p := Package{
+		Targets: map[string][]string{
+			"linux":   {"amd64", "arm/7", "arm/6", "386"},
+			"darwin":  {"amd64", "arm64"},
+			"windows": {"amd64"},
+		},
+		ExcludeTargets: []string{"linux/386", "linux/arm/6"},
+		TargetOverrides: map[string]TargetOverride{
+			"linux":       {Tags: []string{"linux"}, Env: map[string]string{"FOO": "linux"}},
+			"linux/arm/7": {Tags: []string{"armv7"}, Env: map[string]string{"FOO": "armv7"}},
+		},
+	}
+
+	targets, err := p.GetTargets(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.Name())
+	}
+	expected := "darwin/amd64,darwin/arm64,linux/amd64,linux/arm/7,windows/amd64"
+	if strings.Join(names, ",") != expected {
+		t.Errorf("wrong targets (got: %s want: %s)", strings.Join(names, ","), expected)
+	}
+
+	armTarget := targets[3]
+	if armTarget.PkgArch() != "arm-7" || armTarget.VariantEnv() != "GOARM=7" {
+		t.Errorf("wrong variant (%s, %s)", armTarget.PkgArch(), armTarget.VariantEnv())
+	}
+	if strings.Join(armTarget.Tags, ",") != "linux,armv7" || armTarget.Env["FOO"] != "armv7" {
+		t.Errorf("wrong overrides (%v, %v)", armTarget.Tags, armTarget.Env)
+	}
+
+	// Profile targets replace the package ones
+	targets, err = p.GetTargets(&Profile{
+		Targets:        map[string][]string{"linux": {"amd64", "arm64"}, "freebsd": {"amd64"}},
+		ExcludeTargets: []string{"freebsd"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || targets[0].Name() != "linux/amd64" || targets[1].Name() != "linux/arm64" {
+		t.Errorf("wrong profile targets (%v)", targets)
+	}
+
+	// Invalid variant
+	p.Targets = map[string][]string{"linux": {"s390x/foo"}}
+	if _, err := p.GetTargets(nil); err == nil {
+		t.Error("variant on arch without variant should fail")
+	}
+}
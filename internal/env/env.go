@@ -0,0 +1,240 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// Shells are the supported shell syntaxes
+var Shells = []string{"sh", "fish", "powershell"}
+
+// variable is an environment variable set by gopkg
+type variable struct {
+	name  string
+	value string
+	// paths are the directories prepended to the variable (f.e PATH), value is unused
+	paths []string
+}
+
+// Env display the gopkg environment variables using given shell syntax
+// the shell is detected if empty, the bin directories of the project & user scopes are used if scope is empty
+func Env(shell string, scope config.Scope) error {
+	shell, err := getShell(shell)
+	if err != nil {
+		return err
+	}
+
+	vars, err := getVariables(scope)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(format(shell, vars))
+	return nil
+}
+
+// ShellInit display the line to add to the shell rc file to setup the gopkg environment
+func ShellInit(shell string) error {
+	shell, err := getShell(shell)
+	if err != nil {
+		return err
+	}
+
+	switch shell {
+	case "fish":
+		fmt.Println("gopkg env --shell fish | source")
+	case "powershell":
+		fmt.Println("gopkg env --shell powershell | Out-String | Invoke-Expression")
+	default:
+		fmt.Println(`eval "$(gopkg env --shell sh)"`)
+	}
+
+	return nil
+}
+
+// Check make sure the bin directories are in the PATH
+// a warning is logged for each missing directory
+func Check(scope config.Scope) error {
+	binDirs, err := getBinDirs(scope)
+	if err != nil {
+		return err
+	}
+
+	missing := 0
+	for _, dir := range binDirs {
+		if !inPath(dir, os.Getenv("PATH")) {
+			log.Warn().Str("dir", dir).Msg("Bin directory is not in PATH (see gopkg shell-init)")
+			missing++
+		}
+	}
+
+	if missing == 0 {
+		log.Info().Msg("Environment is correctly configured")
+	}
+
+	return nil
+}
+
+// getVariables returns the variables to set for given scope
+// the bin directories already in PATH are not added again
+func getVariables(scope config.Scope) ([]variable, error) {
+	conf, err := config.ForScope(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	binDirs, err := getBinDirs(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var vars []variable
+
+	var paths []string
+	for _, dir := range binDirs {
+		if !inPath(dir, os.Getenv("PATH")) {
+			paths = append(paths, dir)
+		}
+	}
+	if len(paths) > 0 {
+		vars = append(vars, variable{name: "PATH", paths: paths})
+	}
+
+	goPath, err := conf.GetGoPathDir()
+	if err != nil {
+		return nil, err
+	}
+	vars = append(vars, variable{name: "GOPATH", value: filepath.Clean(goPath)})
+
+	if conf.GoFlags != "" {
+		vars = append(vars, variable{name: "GOFLAGS", value: conf.GoFlags})
+	}
+	if conf.Proxy != "" {
+		vars = append(vars, variable{name: "GOPROXY", value: conf.Proxy})
+	}
+
+	return vars, nil
+}
+
+// getBinDirs returns the bin directories of given scope
+// the project (if any) and user bin directories are returned if scope is empty
+func getBinDirs(scope config.Scope) ([]string, error) {
+	scopes := []config.Scope{scope}
+	if scope == "" {
+		scopes = []config.Scope{config.ProjectScope, config.UserScope}
+	}
+
+	var dirs []string
+	for _, s := range scopes {
+		conf, err := config.ForScope(s)
+		if err != nil {
+			// The project scope is not available outside of a project
+			if scope == "" && s == config.ProjectScope {
+				continue
+			}
+			return nil, err
+		}
+		dirs = append(dirs, conf.BinDir)
+	}
+
+	return dirs, nil
+}
+
+// format returns the shell commands setting given variables
+func format(shell string, vars []variable) string {
+	var sb strings.Builder
+	for _, v := range vars {
+		switch shell {
+		case "fish":
+			if v.paths != nil {
+				fmt.Fprintf(&sb, "set -gx %s %s $%s;\n", v.name, joinQuoted(v.paths, " ", quoteFish), v.name)
+			} else {
+				fmt.Fprintf(&sb, "set -gx %s %s;\n", v.name, quoteFish(v.value))
+			}
+		case "powershell":
+			if v.paths != nil {
+				fmt.Fprintf(&sb, "$env:%s = %s + [IO.Path]::PathSeparator + $env:%s\n", v.name,
+					joinQuoted(v.paths, " + [IO.Path]::PathSeparator + ", quotePowershell), v.name)
+			} else {
+				fmt.Fprintf(&sb, "$env:%s = %s\n", v.name, quotePowershell(v.value))
+			}
+		default:
+			if v.paths != nil {
+				fmt.Fprintf(&sb, "export %s=%s:\"$%s\"\n", v.name, joinQuoted(v.paths, ":", quoteSh), v.name)
+			} else {
+				fmt.Fprintf(&sb, "export %s=%s\n", v.name, quoteSh(v.value))
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// getShell returns the shell syntax to use, detected from the environment if empty
+func getShell(shell string) (string, error) {
+	if shell == "" {
+		return detectShell(), nil
+	}
+
+	for _, s := range Shells {
+		if s == shell {
+			return shell, nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid shell %s (supported: %v)", shell, Shells)
+}
+
+func detectShell() string {
+	if runtime.GOOS == "windows" {
+		return "powershell"
+	}
+
+	switch filepath.Base(os.Getenv("SHELL")) {
+	case "fish":
+		return "fish"
+	case "pwsh", "powershell":
+		return "powershell"
+	default:
+		return "sh"
+	}
+}
+
+// inPath returns true if given directory is in the path list
+func inPath(dir, pathList string) bool {
+	for _, p := range filepath.SplitList(pathList) {
+		if p != "" && filepath.Clean(p) == filepath.Clean(dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func joinQuoted(values []string, sep string, quote func(string) string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quote(value)
+	}
+
+	return strings.Join(quoted, sep)
+}
+
+func quoteSh(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func quoteFish(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+func quotePowershell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
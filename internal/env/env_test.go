@@ -0,0 +1,57 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	vars := []variable{
+		{name: "PATH", paths: []string{"/home/me/.gopkg/bin", "/tmp/it's/bin"}},
+		{name: "GOPATH", value: "/home/me/.gopkg"},
+	}
+
+	tests := map[string]string{
+		"sh": "export PATH='/home/me/.gopkg/bin':'/tmp/it'\\''s/bin':\"$PATH\"\n" +
+			"export GOPATH='/home/me/.gopkg'\n",
+		"fish": "set -gx PATH '/home/me/.gopkg/bin' '/tmp/it\\'s/bin' $PATH;\n" +
+			"set -gx GOPATH '/home/me/.gopkg';\n",
+		"powershell": "$env:PATH = '/home/me/.gopkg/bin' + [IO.Path]::PathSeparator + '/tmp/it''s/bin' + [IO.Path]::PathSeparator + $env:PATH\n" +
+			"$env:GOPATH = '/home/me/.gopkg'\n",
+	}
+
+	for shell, want := range tests {
+		if got := format(shell, vars); got != want {
+			t.Errorf("format(%s) = %q, want %q", shell, got, want)
+		}
+	}
+}
+
+func TestGetShell(t *testing.T) {
+	if _, err := getShell("zsh"); err == nil {
+		t.Error("getShell(zsh) should have failed")
+	}
+
+	for _, shell := range Shells {
+		if got, err := getShell(shell); err != nil || got != shell {
+			t.Errorf("getShell(%s) = %s, %v", shell, got, err)
+		}
+	}
+}
+
+func TestInPath(t *testing.T) {
+	pathList := strings.Join([]string{
+		filepath.FromSlash("/usr/bin"),
+		filepath.FromSlash("/home/me/.gopkg/bin/"),
+		filepath.FromSlash("/bin"),
+	}, string(os.PathListSeparator))
+
+	if !inPath(filepath.FromSlash("/home/me/.gopkg/bin"), pathList) {
+		t.Error("/home/me/.gopkg/bin should be in PATH")
+	}
+	if inPath(filepath.FromSlash("/home/me/.gopkg"), pathList) {
+		t.Error("/home/me/.gopkg should not be in PATH")
+	}
+}
@@ -0,0 +1,74 @@
+package hook
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/rs/zerolog/log"
+)
+
+// keptEnvs are the environment variables passed to the hooks
+var keptEnvs = []string{"PATH", "HOME", "TMPDIR"}
+
+// Package describe the package a hook is run for
+type Package struct {
+	// Name is the package name (i.e the alias for binary packages)
+	Name    string
+	Version string
+	// BinDir is the directory where the binaries are installed
+	BinDir string
+}
+
+// Run execute the given hook script for given package
+// the script is run from a temporary directory with a restricted environment:
+// GOPKG_HOOK, GOPKG_PACKAGE, GOPKG_VERSION and GOPKG_BIN_DIR are set, only PATH, HOME & TMPDIR are kept
+// hooks are executable scripts (f.e shell scripts), they cannot be run on windows
+func Run(name string, script []byte, p Package) error {
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("%s hook of %s cannot be run on windows (use --no-scripts to skip the hooks)", name, p.Name)
+	}
+
+	dir, err := ioutil.TempDir("", "gopkg-hook-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, script, 0750); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(path)
+	cmd.Dir = dir
+	cmd.Env = getEnv(name, p)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	log.Info().Str("package", p.Name).Str("hook", name).Msg("Running hook")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook of %s failed: %s", name, p.Name, err)
+	}
+
+	return nil
+}
+
+func getEnv(name string, p Package) []string {
+	var env []string
+	for _, key := range keptEnvs {
+		if value, exist := os.LookupEnv(key); exist {
+			env = append(env, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+
+	return append(env,
+		fmt.Sprintf("GOPKG_HOOK=%s", name),
+		fmt.Sprintf("GOPKG_PACKAGE=%s", p.Name),
+		fmt.Sprintf("GOPKG_VERSION=%s", p.Version),
+		fmt.Sprintf("GOPKG_BIN_DIR=%s", p.BinDir),
+	)
+}
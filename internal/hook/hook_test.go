@@ -0,0 +1,42 @@
+package hook
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		if err := Run("post-install", []byte("exit 0"), Package{Name: "foo"}); err == nil {
+			t.Error("Run should have failed")
+		}
+		return
+	}
+
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.Setenv("GOPKG_TEST_SECRET", "secret")
+	defer os.Unsetenv("GOPKG_TEST_SECRET")
+
+	output := filepath.Join(tmpDir, "output")
+	script := "#!/bin/sh\necho \"$GOPKG_HOOK $GOPKG_PACKAGE $GOPKG_VERSION $GOPKG_BIN_DIR $GOPKG_TEST_SECRET\" > " + output + "\n"
+	p := Package{Name: "foo", Version: "1.0.0-1", BinDir: "/opt/bin"}
+	if err := Run("post-install", []byte(script), p); err != nil {
+		t.Error(err)
+	}
+
+	if b, err := ioutil.ReadFile(output); err != nil || string(b) != "post-install foo 1.0.0-1 /opt/bin \n" {
+		t.Errorf("wrong hook output (%s)", b)
+	}
+
+	if err := Run("pre-remove", []byte("#!/bin/sh\nexit 1\n"), p); err == nil {
+		t.Error("Run should have failed")
+	}
+}
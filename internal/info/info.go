@@ -0,0 +1,109 @@
+package info
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/pkg"
+	"gopkg.in/yaml.v2"
+)
+
+// Info display the build info shipped in given control package
+func Info(path string) error {
+	info, err := readBuildInfo(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(format(info))
+	return nil
+}
+
+// readBuildInfo returns the build info of the control package at given path
+func readBuildInfo(path string) (control.BuildInfo, error) {
+	_, _, _, _, pkgType, err := pkg.ParseFileName(filepath.Base(path))
+	if err != nil {
+		return control.BuildInfo{}, err
+	}
+	if pkgType != pkg.Control {
+		return control.BuildInfo{}, fmt.Errorf("%s is not a control package", filepath.Base(path))
+	}
+
+	content, err := pkg.Read(path)
+	if err != nil {
+		return control.BuildInfo{}, err
+	}
+
+	for name, b := range content {
+		if parts := strings.SplitN(filepath.ToSlash(name), "/", 2); len(parts) != 2 || parts[1] != control.BuildInfoFile {
+			continue
+		}
+
+		var info control.BuildInfo
+		if err := yaml.Unmarshal(b, &info); err != nil {
+			return control.BuildInfo{}, err
+		}
+		return info, nil
+	}
+
+	return control.BuildInfo{}, fmt.Errorf("no build info found in %s", filepath.Base(path))
+}
+
+// format returns the human readable representation of given build info
+func format(info control.BuildInfo) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Import path:  %s\n", info.ImportPath)
+	fmt.Fprintf(&sb, "Version:      %s\n", info.Version)
+	if info.Commit != "" {
+		fmt.Fprintf(&sb, "Commit:       %s\n", info.Commit)
+	}
+	if info.Profile != "" {
+		fmt.Fprintf(&sb, "Profile:      %s\n", info.Profile)
+	}
+	fmt.Fprintf(&sb, "Hermetic:     %t\n", info.Hermetic)
+	fmt.Fprintf(&sb, "Go version:   %s (%s/%s)\n", info.GoVersion, info.HostOs, info.HostArch)
+	fmt.Fprintf(&sb, "Source date:  %s\n", info.SourceDate)
+	fmt.Fprintf(&sb, "Started at:   %s\n", info.StartedAt)
+	fmt.Fprintf(&sb, "Finished at:  %s\n", info.FinishedAt)
+
+	if len(info.Env) > 0 {
+		sb.WriteString("\nEnvironment:\n")
+		for _, key := range sortedKeys(info.Env) {
+			fmt.Fprintf(&sb, "  %s=%s\n", key, info.Env[key])
+		}
+	}
+
+	if len(info.Dependencies) > 0 {
+		sb.WriteString("\nBuild dependencies:\n")
+		for _, dep := range info.Dependencies {
+			version := dep.Version
+			if version == "" {
+				version = "unknown"
+			}
+			fmt.Fprintf(&sb, "  %s %s sha256:%s\n", dep.Name, version, dep.SHA256)
+		}
+	}
+
+	if len(info.Packages) > 0 {
+		sb.WriteString("\nPackages:\n")
+		for _, name := range sortedKeys(info.Packages) {
+			fmt.Fprintf(&sb, "  %s sha256:%s\n", name, info.Packages[name])
+		}
+	}
+
+	return sb.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
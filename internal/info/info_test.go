@@ -0,0 +1,69 @@
+package info
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/pkg"
+)
+
+func TestReadBuildInfo(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	infoPath := filepath.Join(tmpDir, control.BuildInfoFile)
+	if err := ioutil.WriteFile(infoPath, []byte("import_path: github.com/creekorful/foo\nversion: 1.0.0-1\ngo_version: go1.14\n"), 0640); err != nil {
+		t.Error(err)
+	}
+
+	pkgPath := filepath.Join(tmpDir, "github.com-creekorful-foo_1.0.0-1.pkg")
+	entries := []pkg.Entry{{FilePath: infoPath, ArchivePath: filepath.Join("github.com-creekorful-foo_1.0.0-1", control.BuildInfoFile)}}
+	if err := pkg.Write(pkgPath, entries, true); err != nil {
+		t.Error(err)
+	}
+
+	info, err := readBuildInfo(pkgPath)
+	if err != nil {
+		t.Error(err)
+	}
+	if info.ImportPath != "github.com/creekorful/foo" || info.Version != "1.0.0-1" || info.GoVersion != "go1.14" {
+		t.Errorf("wrong build info (%+v)", info)
+	}
+
+	if _, err := readBuildInfo(filepath.Join(tmpDir, "github.com-creekorful-foo-src_1.0.0-1.pkg")); err == nil {
+		t.Error("readBuildInfo should have failed")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	out := format(control.BuildInfo{
+		ImportPath: "github.com/creekorful/foo",
+		Version:    "1.0.0-1",
+		GoVersion:  "go1.14",
+		HostOs:     "linux",
+		HostArch:   "amd64",
+		Env:        map[string]string{"GOPROXY": "off", "CGO_ENABLED": "0"},
+		Dependencies: []control.BuildInfoDependency{
+			{Name: "github.com-rs-zerolog-src", Version: "1.20.0-1", SHA256: "abcd"},
+		},
+	})
+
+	expected := []string{
+		"Import path:  github.com/creekorful/foo\n",
+		"Go version:   go1.14 (linux/amd64)\n",
+		"  CGO_ENABLED=0\n  GOPROXY=off\n",
+		"  github.com-rs-zerolog-src 1.20.0-1 sha256:abcd\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("missing %q in output:\n%s", e, out)
+		}
+	}
+}
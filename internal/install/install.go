@@ -10,23 +10,61 @@ import (
 
 	"github.com/go-pkg-org/gopkg/internal/cache"
 	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/hook"
 	"github.com/go-pkg-org/gopkg/internal/pkg"
+	"github.com/go-pkg-org/gopkg/internal/util"
 	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v2"
 )
 
+// Options are the install options
+type Options struct {
+	// Scope is where the package is installed (default to the user scope)
+	Scope config.Scope
+	// NoScripts disable the package hooks
+	NoScripts bool
+}
+
+// installer hold the state of an install
+type installer struct {
+	conf *config.Config
+	tx   *transaction
+	opts Options
+	// installing are the packages being installed (used to break dependency cycles)
+	installing []string
+}
+
 // Install install given package
-func Install(pkgPath string) error {
-	config, err := config.Default()
+// the runtime dependencies of binary packages are installed from the package directory if needed
+// the install is transactional: on failure (including a failing hook) every change is reverted
+func Install(pkgPath string, opts Options) error {
+	config, err := config.ForScope(opts.Scope)
 	if err != nil {
 		return err
 	}
 
-	c, err := cache.Read(config.CachePath)
+	tx, err := newTransaction(config.CachePath)
 	if err != nil {
 		return err
 	}
 
-	pkgName, _, pkgOs, pkgArch, pkgType, err := pkg.ParseFileName(pkgPath)
+	i := &installer{conf: config, tx: tx, opts: opts}
+	if err := i.install(pkgPath); err != nil {
+		tx.rollback()
+		return err
+	}
+
+	return nil
+}
+
+func (i *installer) install(pkgPath string) error {
+	c, err := cache.Read(i.conf.CachePath)
+	if err != nil {
+		return err
+	}
+
+	pkgName, pkgVersion, pkgOs, pkgArch, pkgType, err := pkg.ParseFileName(filepath.Base(pkgPath))
 	if err != nil {
 		return err
 	}
@@ -38,12 +76,17 @@ func Install(pkgPath string) error {
 	}
 
 	// If binary package override name using alias file
+	var relations control.Relations
 	if pkgType == pkg.Binary {
 		if pkgContent["alias"] == nil {
 			return fmt.Errorf("no alias file found in package")
 		}
 
 		pkgName = string(pkgContent["alias"])
+
+		if relations, err = readRelations(pkgContent); err != nil {
+			return err
+		}
 	}
 
 	// Make sure package is not already installed
@@ -51,48 +94,238 @@ func Install(pkgPath string) error {
 		return fmt.Errorf("package %s is already installed", pkgName)
 	}
 
-	files, err := installFromFile(config, pkgName, pkgOs, pkgArch, pkgType, pkgContent)
+	if err := checkConflicts(c, pkgName, pkgVersion, relations); err != nil {
+		return err
+	}
+
+	i.installing = append(i.installing, pkgName)
+	if err := i.installDependencies(filepath.Dir(pkgPath), relations); err != nil {
+		return err
+	}
+
+	// The dependencies may have updated the cache
+	if c, err = cache.Read(i.conf.CachePath); err != nil {
+		return err
+	}
+
+	hookPkg := hook.Package{Name: pkgName, Version: pkgVersion, BinDir: i.conf.BinDir}
+	if err := i.runHook(control.PreInstallHook, pkgContent, hookPkg); err != nil {
+		return err
+	}
+
+	files, err := installFromFile(i.conf, i.tx, pkgName, pkgOs, pkgArch, pkgType, pkgContent)
 	if err != nil {
 		return err
 	}
 
+	// Keep the remove hooks so they can be run when removing the package
+	for _, name := range []string{control.PreRemoveHook, control.PostRemoveHook} {
+		if script, exist := pkgContent[hookPath(name)]; exist {
+			if err := i.tx.writeFile(filepath.Join(i.conf.HooksDir, pkg.GetName(pkgName, false), name), script, 0750); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := i.runHook(control.PostInstallHook, pkgContent, hookPkg); err != nil {
+		return err
+	}
+
 	// Everything went well, update local cache
 	c.AddPackage(pkgName, files)
-	if err := cache.Write(config.CachePath, c); err != nil {
+	c.SetVersion(pkgName, pkgVersion)
+	if pkgType == pkg.Binary {
+		c.SetRelations(pkgName, relations)
+	}
+	if err := cache.Write(i.conf.CachePath, c); err != nil {
 		return err
 	}
 
-	log.Info().Str("package", pkgName).Msg("Successfully installed package")
+	log.Info().Str("package", pkgName).Str("scope", string(i.conf.Scope)).Msg("Successfully installed package")
 
 	return nil
 }
 
-func installFromFile(config *config.Config, pkgName, pkgOs, pkgArch string, pkgType pkg.Type, pkgContent map[string][]byte) ([]string, error) {
+// runHook run the given hook of the package if any, unless the scripts are disabled
+func (i *installer) runHook(name string, pkgContent map[string][]byte, p hook.Package) error {
+	script, exist := pkgContent[hookPath(name)]
+	if !exist {
+		return nil
+	}
+
+	if i.opts.NoScripts {
+		log.Debug().Str("package", p.Name).Str("hook", name).Msg("Skipping hook")
+		return nil
+	}
+
+	return hook.Run(name, script, p)
+}
+
+// hookPath returns the path of given hook in the binary packages
+func hookPath(name string) string {
+	return control.HooksDir + "/" + name
+}
+
+// readRelations returns the relations of the binary package with given content
+func readRelations(pkgContent map[string][]byte) (control.Relations, error) {
+	var relations control.Relations
+	if b, exist := pkgContent[control.RelationsFile]; exist {
+		if err := yaml.Unmarshal(b, &relations); err != nil {
+			return control.Relations{}, err
+		}
+	}
+
+	if err := relations.Validate(); err != nil {
+		return control.Relations{}, err
+	}
+
+	return relations, nil
+}
+
+// checkConflicts make sure the package does not conflict with the installed packages (and vice versa)
+func checkConflicts(c *cache.Cache, pkgName, pkgVersion string, relations control.Relations) error {
+	for _, conflict := range relations.Conflicts {
+		d, _ := control.ParseDependency(conflict)
+		for _, provider := range c.Providers(d) {
+			if provider != pkgName {
+				return fmt.Errorf("package %s conflicts with installed package %s", pkgName, provider)
+			}
+		}
+	}
+
+	// Check the conflicts declared by the installed packages
+	installed := &cache.Cache{
+		Packages:  map[string][]string{pkgName: {}},
+		Versions:  map[string]string{pkgName: pkgVersion},
+		Relations: map[string]control.Relations{pkgName: relations},
+	}
+	for name := range c.Packages {
+		for _, conflict := range c.GetRelations(name).Conflicts {
+			d, err := control.ParseDependency(conflict)
+			if err == nil && len(installed.Providers(d)) > 0 && name != pkgName {
+				return fmt.Errorf("installed package %s conflicts with package %s", name, pkgName)
+			}
+		}
+	}
+
+	return nil
+}
+
+// installDependencies install the missing dependencies & recommendations found in given directory
+// an error is returned if a dependency cannot be satisfied, missing recommendations are only reported
+func (i *installer) installDependencies(dir string, relations control.Relations) error {
+	var unresolved []string
+	for _, dep := range relations.Depends {
+		if err := i.installDependency(dir, dep); err != nil {
+			unresolved = append(unresolved, fmt.Sprintf("%s (%s)", dep, err))
+		}
+	}
+	if len(unresolved) > 0 {
+		return fmt.Errorf("unresolvable dependencies: %s", strings.Join(unresolved, ", "))
+	}
+
+	for _, recommend := range relations.Recommends {
+		if err := i.installDependency(dir, recommend); err != nil {
+			log.Warn().Str("package", recommend).Str("err", err.Error()).Msg("Recommended package not installed")
+		}
+	}
+
+	return nil
+}
+
+// installDependency install given dependency from given directory unless it is already satisfied
+func (i *installer) installDependency(dir, dep string) error {
+	d, err := control.ParseDependency(dep)
+	if err != nil {
+		return err
+	}
+
+	// Read the cache each time since the previous dependencies may have updated it
+	c, err := cache.Read(i.conf.CachePath)
+	if err != nil {
+		return err
+	}
+
+	if providers := c.Providers(d); len(providers) > 0 {
+		log.Debug().Str("dependency", dep).Str("provider", providers[0]).Msg("Dependency already satisfied")
+		return nil
+	}
+
+	// An installed package is not replaced, it has to be upgraded explicitly
+	if c.GetFiles(d.Name) != nil {
+		return fmt.Errorf("installed version %s of %s does not satisfy %s", c.GetVersion(d.Name), d.Name, dep)
+	}
+
+	// Dependency cycle, the package will be installed
+	if util.Contains(i.installing, d.Name) {
+		return nil
+	}
+
+	pkgPath, err := findBinaryPackage(dir, d)
+	if err != nil {
+		return err
+	}
+	if pkgPath == "" {
+		return fmt.Errorf("no package satisfying %s found", dep)
+	}
+
+	log.Info().Str("dependency", dep).Str("package", pkgPath).Msg("Installing dependency")
+	return i.install(pkgPath)
+}
+
+// findBinaryPackage returns the path of the latest binary package satisfying given dependency
+// for the current platform found in given directory. An empty string is returned if no such package exist
+func findBinaryPackage(dir string, d control.Dependency) (string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	bestPath, bestVersion := "", ""
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), "."+pkg.FileExt) {
+			continue
+		}
+
+		pkgName, pkgVersion, pkgOs, pkgArch, pkgType, err := pkg.ParseFileName(file.Name())
+		if err != nil || pkgType != pkg.Binary || pkgOs != runtime.GOOS || strings.SplitN(pkgArch, "-", 2)[0] != runtime.GOARCH {
+			continue
+		}
+
+		// Binary package files are named after the alias
+		if pkgName != pkg.GetName(d.Name, false) || !d.Match(d.Name, pkgVersion) {
+			continue
+		}
+
+		if bestPath == "" || control.CompareVersions(pkgVersion, bestVersion) > 0 {
+			bestPath, bestVersion = filepath.Join(dir, file.Name()), pkgVersion
+		}
+	}
+
+	return bestPath, nil
+}
+
+func installFromFile(config *config.Config, tx *transaction, pkgName, pkgOs, pkgArch string, pkgType pkg.Type, pkgContent map[string][]byte) ([]string, error) {
 	switch pkgType {
 	case pkg.Source:
-		files, err := installSourcePackage(config, pkgContent)
+		files, err := installSourcePackage(config, tx, pkgContent)
 		return files, err
 	case pkg.Binary:
-		files, err := installBinaryPackage(config, pkgOs, pkgArch, pkgContent)
+		files, err := installBinaryPackage(config, tx, pkgOs, pkgArch, pkgContent)
 		return files, err
 	default:
 		return nil, fmt.Errorf("can't install package %s", pkgName)
 	}
 }
 
-func installSourcePackage(config *config.Config, pkgContent map[string][]byte) ([]string, error) {
+func installSourcePackage(config *config.Config, tx *transaction, pkgContent map[string][]byte) ([]string, error) {
 	var files []string
 	for path, content := range pkgContent {
 		filePath := filepath.Join(config.SrcDir, path)
-		log.Trace().Str("path", filePath).Msg("Writing file")
-
-		// create directory if needed
-		if err := os.MkdirAll(filepath.Dir(filePath), 0750); err != nil {
-			return nil, err
-		}
-
-		// then create file
-		if err := ioutil.WriteFile(filePath, content, 0640); err != nil {
+		if err := tx.writeFile(filePath, content, 0640); err != nil {
 			return nil, err
 		}
 
@@ -102,32 +335,43 @@ func installSourcePackage(config *config.Config, pkgContent map[string][]byte) (
 	return files, nil
 }
 
-func installBinaryPackage(config *config.Config, pkgOs, pkgArch string, pkgContent map[string][]byte) ([]string, error) {
+func installBinaryPackage(config *config.Config, tx *transaction, pkgOs, pkgArch string, pkgContent map[string][]byte) ([]string, error) {
 	if pkgOs != runtime.GOOS {
 		return nil, fmt.Errorf("package not supported for this os (got: %s want: %s)", pkgOs, runtime.GOOS)
 	}
 
-	if pkgArch != runtime.GOARCH {
-		return nil, fmt.Errorf("package not supported for this arch (got: %s want: %s)", pkgArch, runtime.GOARCH)
+	// Ignore the arch variant if any (f.e arm-7)
+	if arch := strings.SplitN(pkgArch, "-", 2)[0]; arch != runtime.GOARCH {
+		return nil, fmt.Errorf("package not supported for this arch (got: %s want: %s)", arch, runtime.GOARCH)
 	}
 
 	var files []string
 	for path, content := range pkgContent {
-		if strings.HasPrefix(path, "bin/") {
-			realPath := filepath.Join(config.BinDir, strings.TrimPrefix(path, "bin/"))
-			log.Trace().Str("path", realPath).Msg("Writing file")
-
-			// create directory if needed
-			if err := os.MkdirAll(filepath.Dir(realPath), 0750); err != nil {
-				return nil, err
-			}
+		var realPath string
+		var perm os.FileMode = 0640
+		switch {
+		case strings.HasPrefix(path, "bin/"):
+			realPath = filepath.Join(config.BinDir, strings.TrimPrefix(path, "bin/"))
+			perm = 0750
+		case strings.HasPrefix(path, "share/"):
+			realPath = filepath.Join(config.ShareDir, filepath.FromSlash(strings.TrimPrefix(path, "share/")))
+		case strings.HasPrefix(path, "etc/"):
+			realPath = filepath.Join(config.EtcDir, filepath.FromSlash(strings.TrimPrefix(path, "etc/")))
 
-			if err := ioutil.WriteFile(realPath, content, 0750); err != nil {
-				return nil, err
+			// Never overwrite the user configuration
+			if _, err := os.Stat(realPath); err == nil {
+				log.Info().Str("path", realPath).Msg("Keeping existing configuration file")
+				continue
 			}
+		default:
+			continue
+		}
 
-			files = append(files, realPath)
+		if err := tx.writeFile(realPath, content, perm); err != nil {
+			return nil, err
 		}
+
+		files = append(files, realPath)
 	}
 
 	return files, nil
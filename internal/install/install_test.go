@@ -0,0 +1,96 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/go-pkg-org/gopkg/internal/cache"
+	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/go-pkg-org/gopkg/internal/control"
+)
+
+func TestCheckConflicts(t *testing.T) {
+	c := &cache.Cache{Packages: map[string][]string{"protoc": nil, "protoc-legacy": nil}}
+	c.SetVersion("protoc", "3.12.0-1")
+	c.SetRelations("protoc-legacy", control.Relations{Conflicts: []string{"protoc-gen-go (< 1.20)"}})
+
+	if err := checkConflicts(c, "protoc-gen-go", "1.25.0-1", control.Relations{}); err != nil {
+		t.Error(err)
+	}
+	if err := checkConflicts(c, "protoc-gen-go", "1.4.0-1", control.Relations{}); err == nil {
+		t.Error("checkConflicts should have failed")
+	}
+	if err := checkConflicts(c, "protoc-gen-go", "1.25.0-1", control.Relations{Conflicts: []string{"protoc (>= 3.0)"}}); err == nil {
+		t.Error("checkConflicts should have failed")
+	}
+}
+
+func TestInstallDependency(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	c := &cache.Cache{Packages: map[string][]string{"protoc": {}}, Versions: map[string]string{}}
+	c.SetVersion("protoc", "3.12.0-1")
+	i := &installer{conf: &config.Config{CachePath: filepath.Join(tmpDir, "cache.json")}}
+	if err := cache.Write(i.conf.CachePath, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := i.installDependency(tmpDir, "protoc (>= 3.0)"); err != nil {
+		t.Error(err)
+	}
+
+	err = i.installDependency(tmpDir, "protoc (>= 3.13)")
+	if err == nil || err.Error() != "installed version 3.12.0-1 of protoc does not satisfy protoc (>= 3.13)" {
+		t.Errorf("wrong error (%v)", err)
+	}
+
+	if err := i.installDependency(tmpDir, "protoc-gen-go"); err == nil {
+		t.Error("installDependency should have failed")
+	}
+}
+
+func TestFindBinaryPackage(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := []string{
+		"github.com-golang-protobuf-protoc-gen-go_1.3.0-1_" + runtime.GOOS + "_" + runtime.GOARCH + ".pkg",
+		"github.com-golang-protobuf-protoc-gen-go_1.4.0-1_" + runtime.GOOS + "_" + runtime.GOARCH + ".pkg",
+		"github.com-golang-protobuf-protoc-gen-go_1.5.0-1_plan9_" + runtime.GOARCH + ".pkg",
+		"github.com-golang-protobuf-src_1.5.0-1.pkg",
+	}
+	for _, file := range files {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, file), []byte{}, 0640); err != nil {
+			t.Error(err)
+		}
+	}
+
+	d, _ := control.ParseDependency("github.com/golang/protobuf/protoc-gen-go (>= 1.0)")
+	path, err := findBinaryPackage(tmpDir, d)
+	if err != nil {
+		t.Error(err)
+	}
+	if path != filepath.Join(tmpDir, files[1]) {
+		t.Errorf("wrong package (%s)", path)
+	}
+
+	d, _ = control.ParseDependency("github.com/golang/protobuf/protoc-gen-go (< 1.4)")
+	if path, _ := findBinaryPackage(tmpDir, d); path != filepath.Join(tmpDir, files[0]) {
+		t.Errorf("wrong package (%s)", path)
+	}
+
+	d, _ = control.ParseDependency("github.com/golang/protobuf/protoc-gen-go (>= 2.0)")
+	if path, _ := findBinaryPackage(tmpDir, d); path != "" {
+		t.Errorf("wrong package (%s)", path)
+	}
+}
@@ -0,0 +1,122 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+)
+
+// transaction record the changes made by an install so they can be reverted
+// the changes made by the hooks outside of the package files are not tracked
+type transaction struct {
+	// files are the written files, in write order
+	files []string
+	// backups are the original content of the written files (nil if the file did not exist)
+	backups map[string]*backup
+	// dirs are the created directories, in creation order
+	dirs []string
+
+	cachePath   string
+	cacheBackup *backup
+}
+
+type backup struct {
+	content []byte
+	mode    os.FileMode
+}
+
+// newTransaction start a transaction, the cache at given path is restored on rollback
+func newTransaction(cachePath string) (*transaction, error) {
+	b, err := readBackup(cachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction{backups: map[string]*backup{}, cachePath: cachePath, cacheBackup: b}, nil
+}
+
+// writeFile write given file, the previous content if any is kept until the transaction ends
+func (t *transaction) writeFile(path string, content []byte, perm os.FileMode) error {
+	if _, exist := t.backups[path]; !exist {
+		b, err := readBackup(path)
+		if err != nil {
+			return err
+		}
+		t.backups[path] = b
+		t.files = append(t.files, path)
+	}
+
+	// Record the directories we create
+	var dirs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil || dir == filepath.Dir(dir) {
+			break
+		}
+		dirs = append([]string{dir}, dirs...)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	t.dirs = append(t.dirs, dirs...)
+
+	log.Trace().Str("path", path).Msg("Writing file")
+	return ioutil.WriteFile(path, content, perm)
+}
+
+// rollback revert the changes made during the transaction
+func (t *transaction) rollback() {
+	log.Warn().Int("files", len(t.files)).Msg("Rolling back install")
+
+	for i := len(t.files) - 1; i >= 0; i-- {
+		if err := restoreBackup(t.files[i], t.backups[t.files[i]]); err != nil {
+			log.Warn().Str("file", t.files[i]).Str("err", err.Error()).Msg("Error while restoring file")
+		}
+	}
+
+	for i := len(t.dirs) - 1; i >= 0; i-- {
+		if err := os.Remove(t.dirs[i]); err != nil && !os.IsNotExist(err) {
+			log.Warn().Str("dir", t.dirs[i]).Str("err", err.Error()).Msg("Error while removing directory")
+		}
+	}
+
+	if err := restoreBackup(t.cachePath, t.cacheBackup); err != nil {
+		log.Warn().Str("err", err.Error()).Msg("Error while restoring cache")
+	}
+}
+
+// readBackup returns the backup of given file, nil if the file does not exist
+func readBackup(path string) (*backup, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &backup{content: content, mode: info.Mode().Perm()}, nil
+}
+
+// restoreBackup restore given file from its backup, the file is removed if the backup is nil
+func restoreBackup(path string, b *backup) error {
+	if b == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := ioutil.WriteFile(path, b.content, b.mode); err != nil {
+		return err
+	}
+
+	// WriteFile does not change the mode of existing files
+	return os.Chmod(path, b.mode)
+}
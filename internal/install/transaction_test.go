@@ -0,0 +1,56 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTransaction_Rollback(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cachePath := filepath.Join(tmpDir, "cache.json")
+	existingPath := filepath.Join(tmpDir, "bin", "foo")
+	if err := os.MkdirAll(filepath.Dir(existingPath), 0750); err != nil {
+		t.Error(err)
+	}
+	if err := ioutil.WriteFile(existingPath, []byte("old foo"), 0700); err != nil {
+		t.Error(err)
+	}
+
+	tx, err := newTransaction(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newPath := filepath.Join(tmpDir, "hooks", "foo", "pre-remove")
+	if err := tx.writeFile(newPath, []byte("#!/bin/sh"), 0750); err != nil {
+		t.Error(err)
+	}
+	if err := tx.writeFile(existingPath, []byte("new foo"), 0750); err != nil {
+		t.Error(err)
+	}
+	if err := ioutil.WriteFile(cachePath, []byte("{}"), 0640); err != nil {
+		t.Error(err)
+	}
+
+	tx.rollback()
+
+	if b, err := ioutil.ReadFile(existingPath); err != nil || string(b) != "old foo" {
+		t.Errorf("file not restored (%s)", b)
+	}
+	if info, err := os.Stat(existingPath); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("file mode not restored (%v)", info.Mode())
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "hooks")); !os.IsNotExist(err) {
+		t.Error("created directories should have been removed")
+	}
+	if _, err := os.Stat(cachePath); !os.IsNotExist(err) {
+		t.Error("cache should have been removed")
+	}
+}
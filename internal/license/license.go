@@ -0,0 +1,202 @@
+package license
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-pkg-org/gopkg/internal/util"
+	"github.com/rs/zerolog/log"
+)
+
+// signature identify a license using phrases found in its text
+type signature struct {
+	// SPDX identifier of the license
+	id string
+	// Phrases (normalized) that must all be found in the text
+	phrases []string
+}
+
+// signatures are the bundled known licenses
+// the most specific licenses must come first since the first match wins
+// (f.e LGPL texts are referencing the GPL, BSD-3-Clause contains BSD-2-Clause text)
+var signatures = []signature{
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2 1"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3 29 june 2007"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2 june 1991"}},
+	{"Apache-2.0", []string{"apache license", "version 2 0"}},
+	{"MPL-2.0", []string{"mozilla public license version 2 0"}},
+	{"EPL-2.0", []string{"eclipse public license v 2 0"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"MIT", []string{"permission is hereby granted free of charge to any person obtaining a copy"}},
+	{"ISC", []string{"permission to use copy modify and or distribute this software for any purpose",
+		"provided that the above copyright notice and this permission notice appear in all copies"}},
+	{"0BSD", []string{"permission to use copy modify and or distribute this software for any purpose"}},
+	{"BSL-1.0", []string{"boost software license version 1 0"}},
+	{"Zlib", []string{"altered source versions must be plainly marked as such",
+		"this notice may not be removed or altered from any source distribution"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"CC0-1.0", []string{"cc0 1 0 universal"}},
+	{"WTFPL", []string{"do what the fuck you want to public license"}},
+}
+
+// gnuVersions are the (normalized) versions of the GNU licenses
+// their SPDX identifier depends on the "or any later version" clause of the upstream notice (f.e GPL-3.0-or-later)
+var gnuVersions = map[string]string{
+	"AGPL-3.0": "3",
+	"LGPL-3.0": "3",
+	"LGPL-2.1": "2 1",
+	"GPL-3.0":  "3",
+	"GPL-2.0":  "2",
+}
+
+// filePrefixes are the (upper case) prefixes of the files containing license text
+var filePrefixes = []string{"LICENSE", "LICENCE", "COPYING", "UNLICENSE"}
+
+// Classify returns the SPDX identifier of the license in given text
+// or an empty string if the license is not known or ambiguous
+func Classify(text string) string {
+	id, _ := classify(text)
+	return id
+}
+
+// classify returns the SPDX identifier of the license in given text
+// ambiguous is true for GNU licenses not telling whether later versions apply
+func classify(text string) (id string, ambiguous bool) {
+	normalized := normalize(text)
+
+	for _, s := range signatures {
+		matches := true
+		for _, phrase := range s.phrases {
+			if !strings.Contains(normalized, phrase) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			if _, exist := gnuVersions[s.id]; exist {
+				id := gnuID(normalized, s.id)
+				return id, id == ""
+			}
+			return s.id, false
+		}
+	}
+
+	return "", false
+}
+
+// gnuID returns the -only or -or-later SPDX identifier of the GNU license in given normalized text
+// an empty string is returned if the text has no notice telling which one applies
+func gnuID(normalized, id string) string {
+	version := gnuVersions[id]
+
+	// Ignore the notice template of the "How to Apply These Terms" appendix
+	if i := strings.Index(normalized, "end of terms and conditions"); i != -1 {
+		normalized = normalized[:i]
+	}
+
+	switch {
+	case strings.Contains(normalized, "version "+version+" of the license or at your option any later version"):
+		return id + "-or-later"
+	case strings.Contains(normalized, "version "+version+" as published by the free software foundation"),
+		strings.Contains(normalized, "version "+version+" only"):
+		return id + "-only"
+	default:
+		return ""
+	}
+}
+
+// Detect returns the SPDX expression describing the licenses
+// found in the license files of given directory
+// several licenses in per license files (f.e LICENSE-MIT & LICENSE-APACHE) are a choice (OR),
+// an empty string is returned if no known license has been found or if the expression is ambiguous
+func Detect(directory string) (string, error) {
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return "", err
+	}
+
+	var ids []string
+	perLicenseFiles := true
+	for _, f := range files {
+		if f.IsDir() || !isLicenseFile(f.Name()) {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(directory, f.Name()))
+		if err != nil {
+			return "", err
+		}
+
+		id, ambiguous := classify(string(b))
+		if ambiguous {
+			log.Warn().Str("file", f.Name()).Msg("License does not tell whether later versions apply, set the license expression manually")
+			return "", nil
+		}
+		if id == "" {
+			log.Warn().Str("file", f.Name()).Msg("Unknown license")
+			continue
+		}
+		log.Trace().Str("file", f.Name()).Str("license", id).Msg("Found license")
+
+		if !util.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+		perLicenseFiles = perLicenseFiles && isPerLicenseFile(f.Name())
+	}
+	sort.Strings(ids)
+
+	if len(ids) > 1 && !perLicenseFiles {
+		log.Warn().Strs("licenses", ids).Msg("Several licenses found, set the license expression manually")
+		return "", nil
+	}
+
+	return strings.Join(ids, " OR "), nil
+}
+
+// isPerLicenseFile returns true if given license file name is suffixed by the license name (f.e LICENSE-MIT)
+// as done by upstreams letting the users choose between several licenses
+func isPerLicenseFile(name string) bool {
+	name = strings.ToUpper(name)
+	for _, prefix := range filePrefixes {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		suffix := strings.TrimPrefix(name, prefix)
+		if suffix == "" || !strings.ContainsAny(suffix[:1], "-_.") {
+			return false
+		}
+		suffix = suffix[1:]
+		return suffix != "" && !util.Contains([]string{"MD", "TXT", "RST"}, suffix)
+	}
+
+	return false
+}
+
+func isLicenseFile(name string) bool {
+	if filepath.Ext(name) == ".go" {
+		return false
+	}
+
+	name = strings.ToUpper(name)
+	for _, prefix := range filePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize lower case given text and replace any sequence of non alphanumeric chars by a single space
+func normalize(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
@@ -0,0 +1,149 @@
+package license
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const mitText = `MIT License
+
+Copyright (c) 2020 Aloïs Micard
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:`
+
+const apacheText = `
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/`
+
+const bsd3Text = `Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+* Neither the name of the copyright holder nor the names of its
+  contributors may be used to endorse or promote products derived from
+  this software without specific prior written permission.`
+
+func TestClassify(t *testing.T) {
+	tests := map[string]string{
+		mitText:    "MIT",
+		apacheText: "Apache-2.0",
+		bsd3Text:   "BSD-3-Clause",
+		"Redistribution and use in source and binary forms, with or without modification":     "BSD-2-Clause",
+		"GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007\n" + laterNotice("3"):     "LGPL-3.0-or-later",
+		"GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007\n" + laterNotice("3"):            "GPL-3.0-or-later",
+		"GNU GENERAL PUBLIC LICENSE\nVersion 2, June 1991\n" + onlyNotice("2"):                "GPL-2.0-only",
+		"GNU LESSER GENERAL PUBLIC LICENSE\nVersion 2.1, February 1999\n" + onlyNotice("2.1"): "LGPL-2.1-only",
+		// Without notice (or with the appendix template only) the license is ambiguous
+		"GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007":                                                                 "",
+		"GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007\nEND OF TERMS AND CONDITIONS\nHow to Apply\n" + laterNotice("3"): "",
+		"All rights reserved.": "",
+	}
+
+	for text, expected := range tests {
+		if id := Classify(text); id != expected {
+			t.Errorf("wrong license (got: %s want: %s)", id, expected)
+		}
+	}
+}
+
+// laterNotice returns the notice of a GNU license applying to given version "or any later version"
+func laterNotice(version string) string {
+	return "This program is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License " +
+		"as published by the Free Software Foundation, either version " + version + " of the License, or (at your option) any later version."
+}
+
+// onlyNotice returns the notice of a GNU license only applying to given version
+func onlyNotice(version string) string {
+	return "This program is free software; you can redistribute it and/or modify it under the terms of the GNU General Public License " +
+		"version " + version + " as published by the Free Software Foundation."
+}
+
+func TestDetect(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"LICENSE-MIT":    mitText,
+		"LICENSE-APACHE": apacheText,
+		"license.go":     "package license",
+		"README.md":      mitText,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0640); err != nil {
+			t.Error(err)
+		}
+	}
+
+	expr, err := Detect(tmpDir)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Dual licensing
+	if expr != "Apache-2.0 OR MIT" {
+		t.Errorf("wrong license expression (%s)", expr)
+	}
+
+	// Several licenses without per license files are left to the maintainer
+	for _, name := range []string{"LICENSE-MIT", "LICENSE-APACHE"} {
+		if err := os.Remove(filepath.Join(tmpDir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "LICENSE"), []byte(mitText), 0640); err != nil {
+		t.Error(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "COPYING.md"), []byte(apacheText), 0640); err != nil {
+		t.Error(err)
+	}
+
+	if expr, err := Detect(tmpDir); err != nil || expr != "" {
+		t.Errorf("wrong license expression (%s, %v)", expr, err)
+	}
+
+	// GNU licenses without notice are left to the maintainer
+	if err := os.Remove(filepath.Join(tmpDir, "COPYING.md")); err != nil {
+		t.Error(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "LICENSE"), []byte("GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007"), 0640); err != nil {
+		t.Error(err)
+	}
+	if expr, err := Detect(tmpDir); err != nil || expr != "" {
+		t.Errorf("wrong license expression (%s, %v)", expr, err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "LICENSE"), []byte("GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007\n"+laterNotice("3")), 0640); err != nil {
+		t.Error(err)
+	}
+	if expr, err := Detect(tmpDir); err != nil || expr != "GPL-3.0-or-later" {
+		t.Errorf("wrong license expression (%s, %v)", expr, err)
+	}
+}
+
+func TestIsPerLicenseFile(t *testing.T) {
+	tests := map[string]bool{
+		"LICENSE-MIT":    true,
+		"license.apache": true,
+		"COPYING_GPL":    true,
+		"LICENSE":        false,
+		"LICENSE.md":     false,
+		"LICENSE-":       false,
+		"LICENSES":       false,
+	}
+
+	for name, expected := range tests {
+		if got := isPerLicenseFile(name); got != expected {
+			t.Errorf("isPerLicenseFile(%s) = %v, want %v", name, got, expected)
+		}
+	}
+}
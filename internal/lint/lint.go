@@ -0,0 +1,434 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/util"
+	"github.com/rs/zerolog/log"
+)
+
+// Severity is the severity of an issue
+type Severity string
+
+const (
+	// Error are issues preventing the package to be built
+	Error Severity = "error"
+	// Warning are issues that should be fixed before uploading the package
+	Warning Severity = "warning"
+	// Info are suggestions
+	Info Severity = "info"
+)
+
+// severityOrder is used to sort issues, most severe first
+var severityOrder = map[Severity]int{Error: 0, Warning: 1, Info: 2}
+
+// Issue is a problem found in a control directory
+type Issue struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// ctrlDir is the control directory being linted
+type ctrlDir struct {
+	path      string
+	metadata  control.Metadata
+	changelog control.Changelog
+}
+
+// rule is a named check run against control directories
+type rule struct {
+	name     string
+	severity Severity
+	// check returns the issue messages
+	check func(c ctrlDir) []string
+}
+
+// rules are the available lint rules
+var rules = []rule{
+	{"empty-changelog", Error, checkEmptyChangelog},
+	{"invalid-release", Error, checkInvalidRelease},
+	{"missing-import-path", Error, checkMissingImportPath},
+	{"missing-maintainer", Error, checkMissingMaintainer},
+	{"duplicate-alias", Error, checkDuplicateAlias},
+	{"duplicate-binary", Error, checkDuplicateBinary},
+	{"invalid-binaries", Error, checkInvalidBinaries},
+	{"missing-main", Error, checkMissingMain},
+	{"invalid-target", Error, checkInvalidTarget},
+	{"invalid-relation", Error, checkInvalidRelation},
+	{"invalid-hook", Error, checkInvalidHook},
+	{"invalid-file", Error, checkInvalidFile},
+	{"todo-description", Warning, checkTodoDescription},
+	{"missing-license", Warning, checkMissingLicense},
+	{"missing-homepage", Info, checkMissingHomepage},
+}
+
+// supportedTargets are the os/arch pairs supported by the go toolchain (go tool dist list)
+// this is a variable so it can be replaced in tests
+var supportedTargets = func() ([]string, error) {
+	b, err := exec.Command("go", "tool", "dist", "list").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error while listing supported targets: %s", err)
+	}
+
+	return strings.Fields(string(b)), nil
+}
+
+// Lint check the control directory at given path and display the issues
+// an error is returned if issues of error severity are found
+func Lint(path string, jsonOutput bool) error {
+	issues, err := Check(path)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(issues); err != nil {
+			return err
+		}
+	} else {
+		Log(issues)
+		if len(issues) == 0 {
+			log.Info().Msg("No issues found")
+		}
+	}
+
+	if errors := Errors(issues); len(errors) > 0 {
+		return fmt.Errorf("%d lint error(s) found", len(errors))
+	}
+
+	return nil
+}
+
+// Check returns the issues of the control directory at given path
+// issues suppressed in metadata are ignored, issues are sorted by severity
+func Check(path string) ([]Issue, error) {
+	m, c, err := control.ReadCtrlDirectory(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return check(ctrlDir{path: path, metadata: m, changelog: c}), nil
+}
+
+// Errors returns the issues of error severity
+func Errors(issues []Issue) []Issue {
+	var errors []Issue
+	for _, issue := range issues {
+		if issue.Severity == Error {
+			errors = append(errors, issue)
+		}
+	}
+
+	return errors
+}
+
+// Log display given issues
+func Log(issues []Issue) {
+	for _, issue := range issues {
+		event := log.Info()
+		switch issue.Severity {
+		case Error:
+			event = log.Error()
+		case Warning:
+			event = log.Warn()
+		}
+
+		event.Str("rule", issue.Rule).Msg(issue.Message)
+	}
+}
+
+func check(c ctrlDir) []Issue {
+	issues := []Issue{}
+	for _, r := range rules {
+		if util.Contains(c.metadata.Lint.Ignore, r.name) {
+			log.Debug().Str("rule", r.name).Msg("Ignoring lint rule")
+			continue
+		}
+
+		for _, message := range r.check(c) {
+			issues = append(issues, Issue{Rule: r.name, Severity: r.severity, Message: message})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return severityOrder[issues[i].Severity] < severityOrder[issues[j].Severity]
+	})
+
+	return issues
+}
+
+func checkEmptyChangelog(c ctrlDir) []string {
+	if len(c.changelog.Releases) == 0 {
+		return []string{"changelog has no release"}
+	}
+	return nil
+}
+
+func checkInvalidRelease(c ctrlDir) []string {
+	var messages []string
+	for i, r := range c.changelog.Releases {
+		if r.Version == "" {
+			messages = append(messages, fmt.Sprintf("release #%d has no version", i+1))
+			continue
+		}
+
+		if r.UpstreamVersion() == r.Version {
+			messages = append(messages, fmt.Sprintf("release %s has no package revision (f.e %s-1)", r.Version, r.Version))
+		}
+
+		if r.Date != "" {
+			if _, err := r.Time(); err != nil {
+				messages = append(messages, fmt.Sprintf("release %s has an invalid date: %s", r.Version, r.Date))
+			}
+		}
+
+		if r.Urgency != "" && !util.Contains(control.Urgencies, r.Urgency) {
+			messages = append(messages, fmt.Sprintf("release %s has an invalid urgency: %s", r.Version, r.Urgency))
+		}
+	}
+
+	return messages
+}
+
+func checkMissingImportPath(c ctrlDir) []string {
+	if c.metadata.ImportPath == "" {
+		return []string{"metadata has no import path"}
+	}
+	return nil
+}
+
+func checkMissingMaintainer(c ctrlDir) []string {
+	for _, maintainer := range c.metadata.Maintainers {
+		if strings.TrimSpace(maintainer) != "" {
+			return nil
+		}
+	}
+	return []string{"metadata has no maintainer"}
+}
+
+func checkDuplicateAlias(c ctrlDir) []string {
+	var aliases []string
+	for _, p := range c.metadata.Packages {
+		aliases = append(aliases, p.Alias)
+	}
+
+	return duplicates("alias", aliases)
+}
+
+func checkDuplicateBinary(c ctrlDir) []string {
+	var binNames []string
+	for _, p := range c.metadata.Packages {
+		for _, binary := range p.GetBinaries() {
+			binNames = append(binNames, binary.BinName)
+		}
+	}
+
+	return duplicates("binary", binNames)
+}
+
+func checkInvalidBinaries(c ctrlDir) []string {
+	var messages []string
+	for _, p := range c.metadata.Packages {
+		if len(p.Binaries) > 0 && (p.Main != "" || p.BinName != "") {
+			messages = append(messages, fmt.Sprintf("package %s has both binaries and main/binname", p.Alias))
+		}
+
+		for _, binary := range p.GetBinaries() {
+			if binary.BinName == "" {
+				messages = append(messages, fmt.Sprintf("package %s has a binary without name", p.Alias))
+			}
+		}
+	}
+
+	return messages
+}
+
+func checkMissingMain(c ctrlDir) []string {
+	var messages []string
+	for _, p := range c.metadata.Packages {
+		for _, binary := range p.GetBinaries() {
+			main := binary.Main
+			if main == "" {
+				main = "."
+			}
+
+			// Main may also be a file of the main package (f.e main.go)
+			pkg, err := control.ImportPackage(filepath.Join(c.path, filepath.FromSlash(main)), p.Targets)
+			if err != nil {
+				messages = append(messages, fmt.Sprintf("package %s main %s is not a go package", p.Alias, main))
+			} else if pkg.Name != "main" {
+				messages = append(messages, fmt.Sprintf("package %s main %s is not a main package", p.Alias, main))
+			}
+		}
+	}
+
+	return messages
+}
+
+func checkInvalidTarget(c ctrlDir) []string {
+	supported, err := supportedTargets()
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var messages []string
+	checkTargets := func(owner string, targets map[string][]string) {
+		for targetOs, targetArches := range targets {
+			for _, targetArch := range targetArches {
+				arch := strings.SplitN(targetArch, "/", 2)[0]
+				if !util.Contains(supported, targetOs+"/"+arch) {
+					messages = append(messages, fmt.Sprintf("%s target %s/%s is not supported", owner, targetOs, targetArch))
+				}
+			}
+		}
+	}
+
+	for _, p := range c.metadata.Packages {
+		checkTargets("package "+p.Alias, p.Targets)
+
+		// Make sure variants & overrides are valid
+		if _, err := p.GetTargets(nil); err != nil {
+			messages = append(messages, fmt.Sprintf("package %s has invalid targets: %s", p.Alias, err))
+		}
+	}
+
+	for _, name := range sortedProfiles(c.metadata.Profiles) {
+		checkTargets("profile "+name, c.metadata.Profiles[name].Targets)
+	}
+
+	sort.Strings(messages)
+	return messages
+}
+
+func checkInvalidRelation(c ctrlDir) []string {
+	var messages []string
+	for _, p := range c.metadata.Packages {
+		if err := p.Relations().Validate(); err != nil {
+			messages = append(messages, fmt.Sprintf("package %s has invalid relations: %s", p.Alias, err))
+		}
+		for _, dep := range p.Depends {
+			if d, err := control.ParseDependency(dep); err == nil && d.Name == p.Alias {
+				messages = append(messages, fmt.Sprintf("package %s depends on itself", p.Alias))
+			}
+		}
+	}
+
+	return messages
+}
+
+func checkInvalidHook(c ctrlDir) []string {
+	hooks, err := control.ReadHooks(c.path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var messages []string
+	for _, name := range control.Hooks {
+		if script, exist := hooks[name]; exist && !strings.HasPrefix(string(script), "#!") {
+			messages = append(messages, fmt.Sprintf("hook %s has no interpreter line (f.e #!/bin/sh)", name))
+		}
+	}
+
+	return messages
+}
+
+func checkInvalidFile(c ctrlDir) []string {
+	var messages []string
+	for _, p := range c.metadata.Packages {
+		for _, f := range p.Files {
+			if err := f.Validate(); err != nil {
+				messages = append(messages, fmt.Sprintf("package %s has an invalid file: %s", p.Alias, err))
+				continue
+			}
+
+			if _, err := p.GetBinary(f.Binary); err != nil {
+				messages = append(messages, fmt.Sprintf("package %s file %s: %s", p.Alias, f.FileName(""), err))
+			}
+
+			if f.Source != "" {
+				if _, err := os.Stat(filepath.Join(c.path, filepath.FromSlash(f.Source))); err != nil {
+					messages = append(messages, fmt.Sprintf("package %s file %s does not exist", p.Alias, f.Source))
+				}
+			}
+		}
+	}
+
+	return messages
+}
+
+func checkTodoDescription(c ctrlDir) []string {
+	var messages []string
+	if isTodo(c.metadata.Description) {
+		messages = append(messages, "metadata description is missing")
+	}
+	for _, p := range c.metadata.Packages {
+		if isTodo(p.Description) {
+			messages = append(messages, fmt.Sprintf("package %s description is missing", p.Alias))
+		}
+	}
+
+	return messages
+}
+
+func checkMissingLicense(c ctrlDir) []string {
+	if c.metadata.License == "" {
+		return []string{"metadata has no license"}
+	}
+	return nil
+}
+
+func checkMissingHomepage(c ctrlDir) []string {
+	if c.metadata.Homepage == "" {
+		return []string{"metadata has no homepage"}
+	}
+	return nil
+}
+
+func isTodo(description string) bool {
+	description = strings.TrimSpace(description)
+	return description == "" || strings.EqualFold(description, "TODO")
+}
+
+// duplicates returns a message for each value used by several packages
+func duplicates(kind string, values []string) []string {
+	counts := map[string]int{}
+	var distinct []string
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		if counts[value] == 0 {
+			distinct = append(distinct, value)
+		}
+		counts[value]++
+	}
+
+	var messages []string
+	for _, value := range distinct {
+		if counts[value] > 1 {
+			messages = append(messages, fmt.Sprintf("%s %s is used by %d packages", kind, value, counts[value]))
+		}
+	}
+
+	return messages
+}
+
+func sortedProfiles(profiles map[string]control.Profile) []string {
+	var names []string
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,143 @@
+package lint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+)
+
+func TestCheck(t *testing.T) {
+	supportedTargets = func() ([]string, error) {
+		return []string{"linux/amd64", "linux/arm", "darwin/amd64"}, nil
+	}
+
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "cmd", "foo"), 0750); err != nil {
+		t.Error(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "cmd", "foo", "main.go"), []byte("package main\n\nfunc main() {}\n"), 0640); err != nil {
+		t.Error(err)
+	}
+
+	m := control.Metadata{
+		ImportPath:  "github.com/creekorful/foo",
+		Homepage:    "https://github.com/creekorful/foo",
+		Description: "Foo",
+		Packages: []control.Package{
+			{Alias: "github.com/creekorful/foo/cmd/foo", Main: "./cmd/foo", BinName: "foo", Description: "Foo",
+				Targets: map[string][]string{"linux": {"amd64", "arm/7"}},
+				Files:   []control.File{{Section: "man", Source: "docs/foo.1"}, {Section: "completions/bash", Command: []string{"completion", "bash"}}}},
+			{Alias: "github.com/creekorful/foo/cmd/foo", Main: "./cmd/bar", BinName: "foo", Description: "TODO",
+				Targets: map[string][]string{"windows": {"amd64"}}, Depends: []string{"protoc (=> 3.0)"}},
+		},
+		Lint: control.Lint{Ignore: []string{"missing-license"}},
+	}
+	if err := control.CreateCtrlDirectory(tmpDir, "1.0.0", "", m); err != nil {
+		t.Error(err)
+	}
+
+	issues, err := Check(tmpDir)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := []Issue{
+		{"missing-maintainer", Error, "metadata has no maintainer"},
+		{"duplicate-alias", Error, "alias github.com/creekorful/foo/cmd/foo is used by 2 packages"},
+		{"duplicate-binary", Error, "binary foo is used by 2 packages"},
+		{"missing-main", Error, "package github.com/creekorful/foo/cmd/foo main ./cmd/bar is not a go package"},
+		{"invalid-target", Error, "package github.com/creekorful/foo/cmd/foo target windows/amd64 is not supported"},
+		{"invalid-relation", Error, "package github.com/creekorful/foo/cmd/foo has invalid relations: invalid operator => in dependency: protoc (=> 3.0)"},
+		{"invalid-file", Error, "package github.com/creekorful/foo/cmd/foo file docs/foo.1 does not exist"},
+		{"todo-description", Warning, "package github.com/creekorful/foo/cmd/foo description is missing"},
+	}
+
+	if len(issues) != len(expected) {
+		t.Fatalf("wrong number of issues (%+v)", issues)
+	}
+	for i, issue := range issues {
+		if issue != expected[i] {
+			t.Errorf("wrong issue (got: %+v want: %+v)", issue, expected[i])
+		}
+	}
+
+	if len(Errors(issues)) != 7 {
+		t.Errorf("wrong number of errors (%d)", len(Errors(issues)))
+	}
+}
+
+func TestCheckChangelog(t *testing.T) {
+	c := ctrlDir{changelog: control.Changelog{Releases: []control.Release{
+		{Version: "1.0.0-1"},
+		{Version: "1.1.0", Date: "yesterday"},
+		{Version: "1.2.0-1", Urgency: "asap"},
+		{},
+	}}}
+
+	if messages := checkEmptyChangelog(c); len(messages) != 0 {
+		t.Errorf("changelog should not be empty (%v)", messages)
+	}
+	if messages := checkEmptyChangelog(ctrlDir{}); len(messages) != 1 {
+		t.Errorf("changelog should be empty (%v)", messages)
+	}
+
+	messages := checkInvalidRelease(c)
+	expected := []string{
+		"release 1.1.0 has no package revision (f.e 1.1.0-1)",
+		"release 1.1.0 has an invalid date: yesterday",
+		"release 1.2.0-1 has an invalid urgency: asap",
+		"release #4 has no version",
+	}
+	if len(messages) != len(expected) {
+		t.Fatalf("wrong messages (%v)", messages)
+	}
+	for i, message := range messages {
+		if message != expected[i] {
+			t.Errorf("wrong message (got: %s want: %s)", message, expected[i])
+		}
+	}
+}
+
+func TestCheckMissingMain(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"main.go":                 "package main\n\nfunc main() {}\n",
+		"cmd/win/main_windows.go": "package main\n\nfunc main() {}\n",
+		"lib/lib.go":              "package lib\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := ctrlDir{path: tmpDir, metadata: control.Metadata{Packages: []control.Package{
+		// Main as a file, as written by older versions of make
+		{Alias: "foo", Main: "main.go", BinName: "foo"},
+		// Main package only building on windows
+		{Alias: "foo/cmd/win", Main: "./cmd/win", BinName: "win", Targets: map[string][]string{"windows": {"amd64"}}},
+		{Alias: "foo/lib", Main: "./lib", BinName: "lib"},
+	}}}
+
+	messages := checkMissingMain(c)
+	if len(messages) != 1 || messages[0] != "package foo/lib main ./lib is not a main package" {
+		t.Errorf("wrong messages (%v)", messages)
+	}
+}
@@ -2,6 +2,7 @@ package list
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/go-pkg-org/gopkg/internal/cache"
 	"github.com/go-pkg-org/gopkg/internal/config"
@@ -9,29 +10,52 @@ import (
 )
 
 // List packages from cache
-func List(onlyInstalled bool) error {
+// the packages of every available scope are listed if scope is empty
+func List(onlyInstalled bool, scope config.Scope) error {
 	if !onlyInstalled {
 		return fmt.Errorf("not implemented at the moment")
 	}
 
-	config, err := config.Default()
-	if err != nil {
-		return err
+	scopes := []config.Scope{scope}
+	if scope == "" {
+		scopes = config.Scopes
 	}
 
-	c, err := cache.Read(config.CachePath)
-	if err != nil {
-		return err
+	found := false
+	for _, s := range scopes {
+		conf, err := config.ForScope(s)
+		if err != nil {
+			// The project scope is not available outside of a project
+			if scope == "" && s == config.ProjectScope {
+				continue
+			}
+			return err
+		}
+
+		c, err := cache.Read(conf.CachePath)
+		if err != nil {
+			return err
+		}
+
+		for _, pkg := range sortedPackages(c) {
+			log.Info().Str("package", pkg).Str("version", c.GetVersion(pkg)).Str("scope", string(s)).Msg("")
+			found = true
+		}
 	}
 
-	if len(c.Packages) == 0 {
+	if !found {
 		log.Info().Msg("No packages installed")
-		return nil
 	}
 
+	return nil
+}
+
+func sortedPackages(c *cache.Cache) []string {
+	var pkgs []string
 	for pkg := range c.Packages {
-		log.Info().Str("package", pkg).Msg("")
+		pkgs = append(pkgs, pkg)
 	}
+	sort.Strings(pkgs)
 
-	return nil
+	return pkgs
 }
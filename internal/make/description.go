@@ -0,0 +1,77 @@
+package make
+
+import (
+	"go/build"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// readmeFiles are the README file names, by order of preference
+var readmeFiles = []string{"README.md", "README", "README.txt", "README.rst", "readme.md", "Readme.md"}
+
+// linkRegex match markdown links & images: [text](url)
+var linkRegex = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+
+// getDescription returns the upstream project description
+// using the root package documentation, or the README as fallback
+func getDescription(directory string) (string, error) {
+	if p, err := build.ImportDir(directory, 0); err == nil && p.Doc != "" {
+		return p.Doc, nil
+	}
+
+	for _, name := range readmeFiles {
+		b, err := ioutil.ReadFile(filepath.Join(directory, name))
+		if err != nil {
+			continue
+		}
+
+		return parseReadme(string(b)), nil
+	}
+
+	return "", nil
+}
+
+// parseReadme extract the first paragraph of given README
+// skipping the titles, badges, images & html tags
+func parseReadme(content string) string {
+	var lines []string
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			// End of the paragraph
+			if len(lines) > 0 {
+				break
+			}
+			continue
+		}
+
+		// Setext titles: the previous line was the title
+		if strings.Trim(line, "=-*") == "" {
+			if len(lines) == 1 {
+				lines = nil
+				continue
+			}
+			if len(lines) > 0 {
+				break
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "<") || strings.HasPrefix(line, "![") ||
+			strings.HasPrefix(line, "[![") || strings.HasPrefix(line, "```") {
+			// Titles ends the paragraph too
+			if len(lines) > 0 {
+				break
+			}
+			continue
+		}
+
+		lines = append(lines, linkRegex.ReplaceAllString(line, "$1"))
+	}
+
+	return strings.Join(lines, " ")
+}
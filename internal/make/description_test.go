@@ -0,0 +1,55 @@
+package make
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseReadme(t *testing.T) {
+	tests := map[string]string{
+		"# gopkg\n\n[![Build](https://badge)](https://ci)\n\nPackage manager for Golang\nwritten applications.\n\n## Install\n": "Package manager for Golang written applications.",
+		"gopkg\n=====\n\nA [package](https://github.com) manager.\n":                                                            "A package manager.",
+		"<p align=\"center\"><img src=\"logo.png\"></p>\n\nHello world\n# Usage\n":                                              "Hello world",
+		"# Title only\n": "",
+	}
+
+	for content, expected := range tests {
+		if d := parseReadme(content); d != expected {
+			t.Errorf("wrong description (got: %s want: %s)", d, expected)
+		}
+	}
+}
+
+func TestGetDescription(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "README.md"), []byte("# foo\n\nFoo from README.\n"), 0640); err != nil {
+		t.Error(err)
+	}
+
+	d, err := getDescription(tmpDir)
+	if err != nil {
+		t.Error(err)
+	}
+	if d != "Foo from README." {
+		t.Errorf("wrong description (%s)", d)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "foo.go"), []byte("// Package foo is the foo library.\npackage foo\n"), 0640); err != nil {
+		t.Error(err)
+	}
+
+	d, err = getDescription(tmpDir)
+	if err != nil {
+		t.Error(err)
+	}
+	if d != "Package foo is the foo library." {
+		t.Errorf("wrong description (%s)", d)
+	}
+}
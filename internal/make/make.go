@@ -2,22 +2,26 @@ package make
 
 import (
 	"fmt"
+	"go/build"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/go-pkg-org/gopkg/internal/config"
 	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/license"
 	"github.com/go-pkg-org/gopkg/internal/pkg"
 	"github.com/go-pkg-org/gopkg/internal/util"
 	"github.com/rs/zerolog/log"
 )
 
 // Make create a brand new control package from given import path
-func Make(importPath string) error {
+// if group is true the binaries are grouped in packages based on the directory layout, otherwise each binary has its own package
+func Make(importPath string, group bool) error {
 	directory := pkg.GetName(importPath, false)
 
 	if _, err := os.Stat(directory); err == nil {
@@ -37,47 +41,47 @@ func Make(importPath string) error {
 	// Remove any leading v since we doesn't want it in gopkg archive
 	cleanVersion := strings.TrimPrefix(version, "v")
 
-	// Get defined importPaths (dependencies)
-	deps, err := getImportPaths(directory)
+	// Get the build dependencies
+	buildDepends, err := getBuildDependencies(importPath, directory)
 	if err != nil {
 		return err
 	}
 
-	// Get std dependencies (builtin)
-	stdDeps, err := getStdDeps()
+	// Extract the upstream details
+	description, err := getDescription(directory)
 	if err != nil {
 		return err
 	}
 
-	// Then get its dependencies
-	missingDeps, err := getMissingDeps(deps, stdDeps, importPath)
+	licenseExpr, err := license.Detect(directory)
 	if err != nil {
 		return err
 	}
-
-	if len(missingDeps) > 0 {
-		log.Warn().Strs("dependencies", missingDeps).Msg("Dependencies that need to be packaged first")
-	}
-
-	// Convert dependencies into package name
-	var buildDepends []string
-	for _, missingDep := range missingDeps {
-		buildDepends = append(buildDepends, pkg.GetName(missingDep, true))
+	if licenseExpr == "" {
+		log.Warn().Msg("No license detected")
 	}
 
 	m := control.Metadata{
 		Maintainers:       []string{config.GetMaintainerEntry()},
 		Packages:          []control.Package{},
 		ImportPath:        importPath,
+		Description:       description,
+		Homepage:          fmt.Sprintf("https://%s", importPath),
+		License:           licenseExpr,
 		BuildDependencies: buildDepends,
 	}
 
 	// Search for binary packages
-	binPkgs, err := getBinaryPackages(importPath, directory)
+	binPkgs, err := getBinaryPackages(importPath, directory, config.DefaultTargets)
 	if err != nil {
 		return err
 	}
-	m.Packages = append(m.Packages, binPkgs...)
+	if group {
+		binPkgs = groupBinaryPackages(importPath, binPkgs)
+	} else if len(binPkgs) > 1 {
+		log.Info().Int("binaries", len(binPkgs)).Msg("Creating a package per binary, use --group to group them by directory")
+	}
+	m.Packages = append(m.Packages, withDefaultDescription(binPkgs, description)...)
 
 	// Create the control directory
 	if err := control.CreateCtrlDirectory(directory, cleanVersion, config.GetMaintainerEntry(), m); err != nil {
@@ -87,6 +91,7 @@ func Make(importPath string) error {
 	log.Info().
 		Str("import-path", importPath).
 		Str("version", cleanVersion).
+		Str("license", licenseExpr).
 		Msg("Detected values")
 	for _, p := range m.Packages {
 		log.Info().Str("package", p.Alias).Msg("Built package")
@@ -95,6 +100,39 @@ func Make(importPath string) error {
 	return nil
 }
 
+// getBuildDependencies returns the source packages needed to build the package located in directory
+func getBuildDependencies(importPath, directory string) ([]string, error) {
+	// Get defined importPaths (dependencies)
+	deps, err := getImportPaths(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get std dependencies (builtin)
+	stdDeps, err := getStdDeps()
+	if err != nil {
+		return nil, err
+	}
+
+	// Then get its dependencies
+	missingDeps, err := getMissingDeps(deps, stdDeps, importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(missingDeps) > 0 {
+		log.Warn().Strs("dependencies", missingDeps).Msg("Dependencies that need to be packaged first")
+	}
+
+	// Convert dependencies into package name
+	var buildDepends []string
+	for _, missingDep := range missingDeps {
+		buildDepends = append(buildDepends, pkg.GetName(missingDep, true))
+	}
+
+	return buildDepends, nil
+}
+
 // Get the package missing dependencies dependencies
 // - remove the 'std' dependencies (builtin)
 // - remove the dependencies that belongs to the project we want to package
@@ -177,77 +215,309 @@ func parseLines(b []byte) []string {
 	return strings.Split(output, "\n")
 }
 
-// getExecutables will lookup for executable in given directory and returns their corresponding package
-func getBinaryPackages(importPath, directory string) ([]control.Package, error) {
+// skippedDirs are the directories ignored while searching for binary packages
+// the go tool ignores testdata & vendor, examples are generally not meant to be packaged
+var skippedDirs = []string{"testdata", "vendor", "example", "examples", "_examples"}
+
+// getBinaryPackages will lookup for main packages in given directory and returns their corresponding package
+// binaries are named after their package directory, as `go install` would do
+func getBinaryPackages(importPath, directory string, targets map[string][]string) ([]control.Package, error) {
 	var pkgs []control.Package
 
-	if err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+	if err := filepath.Walk(directory, func(dir string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if info.IsDir() {
+		if !info.IsDir() {
+			return nil
+		}
+
+		// Ignore the same directories as the go tool does, and the examples ones
+		name := info.Name()
+		if dir != directory && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+			util.Contains(skippedDirs, name)) {
+			return filepath.SkipDir
+		}
+
+		// The build constraints are respected (using the host then the targets os/arch)
+		// and the test files ignored
+		p, err := control.ImportPackage(dir, targets)
+		if err != nil {
+			if _, ok := err.(*build.NoGoError); ok {
+				return nil
+			}
+			log.Warn().Str("dir", dir).Str("err", err.Error()).Msg("Error while parsing package")
+			return nil
+		}
+
+		if p.Name != "main" {
 			return nil
 		}
 
-		b, err := ioutil.ReadFile(path)
+		relPath, err := filepath.Rel(directory, dir)
 		if err != nil {
 			return err
 		}
 
-		if strings.Contains(string(b), "func main()") && strings.HasSuffix(path, ".go") {
-			fileName := strings.Replace(info.Name(), ".go", "", 1)
-			aliasName := filepath.Join(importPath, fileName)
-			pkgs = append(pkgs, control.Package{
-				Alias:       aliasName,
-				Description: "TODO",
-				Main:        strings.TrimPrefix(path, directory+"/"),
-				BinName:     strings.ReplaceAll(aliasName, "/", "-"),
-				Targets:     getDefaultTargets(),
-			})
-			log.Trace().Str("file", path).Str("alias", aliasName).Msg("Found binary package")
+		aliasName := importPath
+		main := "."
+		if relPath != "." {
+			aliasName = filepath.ToSlash(filepath.Join(importPath, relPath))
+			main = "./" + filepath.ToSlash(relPath)
 		}
+
+		// Use the package documentation as description
+		description := p.Doc
+		if description == "" {
+			description = "TODO"
+		}
+
+		pkgs = append(pkgs, control.Package{
+			Alias:       aliasName,
+			Description: description,
+			Main:        main,
+			BinName:     path.Base(aliasName),
+			Targets:     targets,
+		})
+		log.Trace().Str("dir", dir).Str("alias", aliasName).Msg("Found binary package")
+
 		return nil
 	}); err != nil {
 		return nil, err
 	}
 
-	return pkgs, nil
+	return uniqueBinNames(pkgs), nil
+}
+
+// uniqueBinNames rename the binaries having the same name (f.e the root package & cmd/foo both named foo)
+// the root binary (or the first one) keeps its name, the others are named after their directory (f.e cmd-foo)
+func uniqueBinNames(pkgs []control.Package) []control.Package {
+	owners := map[string]int{}
+	for i, p := range pkgs {
+		if owner, exist := owners[p.BinName]; !exist || (p.Main == "." && pkgs[owner].Main != ".") {
+			owners[p.BinName] = i
+		}
+	}
+
+	for i, p := range pkgs {
+		if owners[p.BinName] == i {
+			continue
+		}
+
+		binName := strings.ReplaceAll(strings.TrimPrefix(p.Main, "./"), "/", "-")
+		log.Info().Str("package", p.Alias).Str("binary", binName).Msgf("Binary name %s already used, renaming", p.BinName)
+		pkgs[i].BinName = binName
+	}
+
+	return pkgs
+}
+
+// groupBinaryPackages propose a grouping of the binary packages based on the directory layout
+// binaries living in the same directory (f.e cmd/bar & cmd/baz) are grouped in a single package
+// named <import path>-extras (or <import path>-<directory> for directories other than cmd).
+// The primary binary (at the root or named after the project) is kept in its own package
+func groupBinaryPackages(importPath string, pkgs []control.Package) []control.Package {
+	pkgs = uniqueBinNames(pkgs)
+	project := path.Base(importPath)
+
+	primary := -1
+	for i, p := range pkgs {
+		if p.Main == "." {
+			primary = i
+			break
+		}
+		if primary == -1 && p.BinName == project {
+			primary = i
+		}
+	}
+
+	// Group the other binaries by parent directory
+	var aliases []string
+	groups := map[string][]control.Package{}
+	for i, p := range pkgs {
+		if i == primary {
+			continue
+		}
+
+		dir := path.Base(path.Dir(p.Main))
+		if dir == "." || dir == "cmd" {
+			dir = "extras"
+		}
+		alias := importPath + "-" + dir
+
+		if _, exist := groups[alias]; !exist {
+			aliases = append(aliases, alias)
+		}
+		groups[alias] = append(groups[alias], p)
+	}
+
+	var grouped []control.Package
+	for _, alias := range aliases {
+		group := groups[alias]
+		if len(group) == 1 {
+			grouped = append(grouped, group[0])
+			continue
+		}
+
+		p := control.Package{Alias: alias, Description: "TODO", Targets: group[0].Targets}
+		var binNames []string
+		for _, g := range group {
+			p.Binaries = append(p.Binaries, control.Binary{Main: g.Main, BinName: g.BinName})
+			binNames = append(binNames, g.BinName)
+		}
+		grouped = append(grouped, p)
+		log.Info().Str("package", alias).Strs("binaries", binNames).Msg("Grouped binaries")
+	}
+
+	if primary == -1 {
+		return grouped
+	}
+
+	// The primary binary is named after the project once the others are grouped
+	p := pkgs[primary]
+	if len(grouped) < len(pkgs)-1 {
+		p.Alias = importPath
+	}
+
+	return append([]control.Package{p}, grouped...)
+}
+
+// GetUpstreamVersion returns the latest available upstream version for given import path
+// the leading v is removed, as done when making the package
+// the highest tag is read from the remote without cloning it, untagged upstreams are shallow cloned
+// this is the version fetched by Make & Update
+func GetUpstreamVersion(importPath string) (string, error) {
+	remote := getRemote(importPath)
+
+	tag, err := getRemoteTag(remote)
+	if err != nil {
+		return "", err
+	}
+	if tag != "" {
+		return strings.TrimPrefix(tag, "v"), nil
+	}
+
+	// No tags: the version is computed from the latest commit date
+	tmpDir, err := ioutil.TempDir("", "gopkg-upstream-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cmd := exec.Command("git", "clone", "--depth", "1", remote, tmpDir)
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error: git clone %s result (%s)", remote, err)
+	}
+
+	version, _, err := getGitVersion(tmpDir)
+	if err != nil {
+		return "", err
+	}
+
+	return version, nil
+}
+
+// getRemoteTag returns the highest tag of given remote, empty if none
+func getRemoteTag(remote string) (string, error) {
+	cmd := exec.Command("git", "ls-remote", "--tags", remote)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error: git ls-remote %s result (%s)", remote, err)
+	}
+
+	return getLatestTag(string(output)), nil
+}
+
+// getLatestTag returns the highest tag listed in given `git ls-remote --tags` output, empty if none
+func getLatestTag(output string) string {
+	latest := ""
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "refs/tags/") {
+			continue
+		}
+
+		// Skip the peeled annotated tags (refs/tags/v1.0.0^{})
+		tag := strings.TrimPrefix(fields[1], "refs/tags/")
+		if strings.HasSuffix(tag, "^{}") {
+			continue
+		}
+
+		if latest == "" || control.CompareVersions(strings.TrimPrefix(tag, "v"), strings.TrimPrefix(latest, "v")) > 0 {
+			latest = tag
+		}
+	}
+
+	return latest
+}
+
+// getRemote returns the git remote of given import path
+func getRemote(importPath string) string {
+	return fmt.Sprintf("https://%s.git", importPath)
+}
+
+// withDefaultDescription set given description to the packages not having one
+func withDefaultDescription(pkgs []control.Package, description string) []control.Package {
+	if description == "" {
+		return pkgs
+	}
+
+	for i := range pkgs {
+		if pkgs[i].Description == "TODO" {
+			pkgs[i].Description = description
+		}
+	}
+
+	return pkgs
 }
 
 // getUpstreamSource fetch latest available upstream source
 // this method return path to upstream source, version, and error if any
 func getUpstreamSource(importPath, where string) (string, error) {
-	remote := fmt.Sprintf("https://%s.git", importPath)
+	remote := getRemote(importPath)
 	log.Debug().Str("remote", remote).Msg("Found upstream remote")
 
-	// Clone repository
+	// Use the highest tag, as reported by GetUpstreamVersion (it may not be on the default branch)
+	tag, err := getRemoteTag(remote)
+	if err != nil {
+		return "", err
+	}
+
+	// Clone repository, checking out the tag if any
+	args := []string{"clone", remote, where}
+	if tag != "" {
+		log.Debug().Str("tag", tag).Msg("Found upstream tag")
+		args = []string{"clone", "--branch", tag, remote, where}
+	}
+
 	log.Debug().Str("remote", remote).Msg("Cloning remote")
-	cmd := exec.Command("git", "clone", remote, where)
+	cmd := exec.Command("git", args...)
 	if err := cmd.Run(); err != nil {
 		return "", fmt.Errorf("error: git clone %s result (%s)", remote, err)
 	}
 
-	// Get git repository latest version
-	version, isTag, err := getGitVersion(where)
-	if err != nil {
-		return "", err
+	if tag != "" {
+		return tag, nil
 	}
-	log.Debug().Str("version", version).Bool("tagged", isTag).Msg("Found upstream version")
 
-	// if this is a tagged release, checkout it to align source code
-	if isTag {
-		log.Debug().Str("tag", version).Msg("Checking out tag")
-		cmd = exec.Command("git", "checkout", version)
-		cmd.Dir = where
-		if err := cmd.Run(); err != nil {
-			return "", err
-		}
+	// Untagged upstream, use the latest commit
+	version, _, err := getGitVersion(where)
+	if err != nil {
+		return "", err
 	}
+	log.Debug().Str("version", version).Msg("Found upstream version")
 
 	return version, nil
 }
 
+// SnapshotVersion returns the version of an untagged upstream at given commit date (f.e 0.0~git202010151805)
+// the date is in UTC like the go module pseudo-versions, and zero padded so the snapshots compare in date order
+// (older unpadded snapshots are shorter, hence older)
+func SnapshotVersion(date time.Time) string {
+	return "0.0~git" + date.UTC().Format("200601021504")
+}
+
 // getGitVersion will attempt to auto-detect the latest stable/tagged release
 // if upstream tag release: it will return the latest tag
 // if upstream doesn't tag release: it will create a special version for the latest (HEAD) commit
@@ -272,15 +542,8 @@ func getGitVersion(gitDir string) (string, bool, error) {
 			return "", false, err
 		}
 
-		return fmt.Sprintf("0.0~git%d%d%d%d%d", date.Year(), date.Month(), date.Day(), date.Hour(), date.Minute()), false, nil
+		return SnapshotVersion(date), false, nil
 	}
 
 	return strings.TrimSuffix(string(b), "\n"), true, nil
 }
-
-func getDefaultTargets() map[string][]string {
-	return map[string][]string{
-		"linux":  {"amd64"},
-		"darwin": {"amd64"},
-	}
-}
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
 )
 
 func TestGetGitVersion(t *testing.T) {
@@ -43,7 +45,7 @@ func TestGetGitVersion(t *testing.T) {
 		t.Error("Git version should not be a tag")
 	}
 
-	if v != "0.0~git20201015205" {
+	if v != "0.0~git202010151805" {
 		t.Errorf("Wrong git version (%s)", v)
 	}
 
@@ -66,6 +68,83 @@ func TestGetGitVersion(t *testing.T) {
 	}
 }
 
+func TestGetLatestTag(t *testing.T) {
+	output := "a1\trefs/tags/v1.9.0\n" +
+		"a2\trefs/tags/v1.10.0\n" +
+		"a3\trefs/tags/v1.10.0^{}\n" +
+		"a4\trefs/tags/v1.2.0\n" +
+		"a5\trefs/heads/v2.0.0\n"
+
+	if tag := getLatestTag(output); tag != "v1.10.0" {
+		t.Errorf("wrong latest tag (%s)", tag)
+	}
+
+	if tag := getLatestTag(""); tag != "" {
+		t.Errorf("wrong latest tag (%s)", tag)
+	}
+}
+
+func TestGetUpstreamSource(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// example.com/foo is served by the local repository remotes/foo.git
+	// v1.0.0 is on the default branch, v2.0.0 on a release branch
+	upstreamDir := filepath.Join(tmpDir, "upstream")
+	if err := os.MkdirAll(upstreamDir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := runGitCmd(upstreamDir, nil, "init"); err != nil {
+		t.Fatal(err)
+	}
+	for _, release := range []struct{ branch, tag string }{{"", "v1.0.0"}, {"release-2", "v2.0.0"}} {
+		if release.branch != "" {
+			if err := runGitCmd(upstreamDir, nil, "checkout", "-b", release.branch); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := ioutil.WriteFile(filepath.Join(upstreamDir, "VERSION"), []byte(release.tag), 0640); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{{"add", "VERSION"}, {"commit", "-m", release.tag}, {"tag", release.tag}} {
+			if err := runGitCmd(upstreamDir, nil, args...); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := runGitCmd(upstreamDir, nil, "checkout", "-"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runGitCmd(tmpDir, nil, "clone", "--bare", upstreamDir, filepath.Join(tmpDir, "remotes", "foo.git")); err != nil {
+		t.Fatal(err)
+	}
+
+	gitConfig := fmt.Sprintf("[url \"%s/\"]\n\tinsteadOf = https://example.com/\n", filepath.ToSlash(filepath.Join(tmpDir, "remotes")))
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, ".gitconfig"), []byte(gitConfig), 0640); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", tmpDir)
+
+	// Make / Update & watch should agree on the version
+	v, err := GetUpstreamVersion("example.com/foo")
+	if err != nil || v != "2.0.0" {
+		t.Errorf("wrong upstream version (%s, %v)", v, err)
+	}
+
+	srcDir := filepath.Join(tmpDir, "src")
+	v, err = getUpstreamSource("example.com/foo", srcDir)
+	if err != nil || v != "v2.0.0" {
+		t.Errorf("wrong upstream source version (%s, %v)", v, err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(srcDir, "VERSION")); err != nil || string(b) != "v2.0.0" {
+		t.Errorf("wrong upstream source checked out (%s, %v)", b, err)
+	}
+}
+
 func TestGetMissingDeps(t *testing.T) {
 	deps := []string{"github.com/jedib0t/go-pretty/v6/table", "github.com/jedib0t/go-pretty/v6/text",
 		"github.com/muesli/termenv", "golang.org/x/crypto/ssh/terminal", "golang.org/x/sys/unix",
@@ -95,6 +174,110 @@ func TestGetMissingDeps(t *testing.T) {
 	}
 }
 
+func TestGetBinaryPackages(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"main.go":                 "package main\n\nfunc main() {}\n",
+		"cmd/foo/foo.go":          "package main\n\nfunc main() {}\n",
+		"cmd/foo/foo_test.go":     "package main\n",
+		"cmd/win/main_windows.go": "package main\n\nfunc main() {}\n",
+		"cmd/bar/bar.go":          "// +build ignore\n\npackage main\n\nfunc main() {}\n",
+		"lib/lib.go":              "package lib\n\n// see func main() in cmd/foo\n",
+		"lib/gen.go":              "// +build ignore\n\npackage main\n\nfunc main() {}\n",
+		"testdata/main.go":        "package main\n\nfunc main() {}\n",
+		"vendor/x/main.go":        "package main\n\nfunc main() {}\n",
+		"examples/hello/hello.go": "package main\n\nfunc main() {}\n",
+	}
+	for path, content := range files {
+		path = filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Error(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0640); err != nil {
+			t.Error(err)
+		}
+	}
+
+	targets := map[string][]string{"linux": {"amd64"}, "windows": {"amd64"}}
+	pkgs, err := getBinaryPackages("github.com/creekorful/foo", tmpDir, targets)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(pkgs) != 3 {
+		t.Fatalf("Wrong number of binary packages found (%d)", len(pkgs))
+	}
+
+	if pkgs[0].Alias != "github.com/creekorful/foo" || pkgs[0].BinName != "foo" || pkgs[0].Main != "." {
+		t.Errorf("Wrong root binary package (%+v)", pkgs[0])
+	}
+
+	// The root package keeps the foo binary name
+	if pkgs[1].Alias != "github.com/creekorful/foo/cmd/foo" || pkgs[1].BinName != "cmd-foo" || pkgs[1].Main != "./cmd/foo" {
+		t.Errorf("Wrong cmd binary package (%+v)", pkgs[1])
+	}
+
+	// Found even if it does not build on the host
+	if pkgs[2].Alias != "github.com/creekorful/foo/cmd/win" || pkgs[2].BinName != "win" || pkgs[2].Main != "./cmd/win" {
+		t.Errorf("Wrong windows binary package (%+v)", pkgs[2])
+	}
+
+	if len(pkgs[1].Targets["linux"]) != 1 || pkgs[1].Targets["linux"][0] != "amd64" {
+		t.Errorf("Wrong binary package targets (%v)", pkgs[1].Targets)
+	}
+}
+
+func TestGroupBinaryPackages(t *testing.T) {
+	pkgs := []control.Package{
+		{Alias: "github.com/creekorful/foo/cmd/bar", Main: "./cmd/bar", BinName: "bar"},
+		{Alias: "github.com/creekorful/foo/cmd/foo", Main: "./cmd/foo", BinName: "foo"},
+		{Alias: "github.com/creekorful/foo/cmd/baz", Main: "./cmd/baz", BinName: "baz"},
+		{Alias: "github.com/creekorful/foo/tools/gen", Main: "./tools/gen", BinName: "gen"},
+	}
+
+	grouped := groupBinaryPackages("github.com/creekorful/foo", pkgs)
+	if len(grouped) != 3 {
+		t.Fatalf("wrong number of packages (%+v)", grouped)
+	}
+
+	if grouped[0].Alias != "github.com/creekorful/foo" || grouped[0].BinName != "foo" {
+		t.Errorf("wrong primary package (%+v)", grouped[0])
+	}
+
+	extras := grouped[1]
+	if extras.Alias != "github.com/creekorful/foo-extras" || len(extras.Binaries) != 2 ||
+		extras.Binaries[0] != (control.Binary{Main: "./cmd/bar", BinName: "bar"}) ||
+		extras.Binaries[1] != (control.Binary{Main: "./cmd/baz", BinName: "baz"}) {
+		t.Errorf("wrong extras package (%+v)", extras)
+	}
+
+	if grouped[2].Alias != "github.com/creekorful/foo/tools/gen" {
+		t.Errorf("wrong tools package (%+v)", grouped[2])
+	}
+
+	// The root binary is primary, cmd/foo must not keep the same binary name
+	rooted := groupBinaryPackages("github.com/creekorful/foo", []control.Package{
+		{Alias: "github.com/creekorful/foo", Main: ".", BinName: "foo"},
+		{Alias: "github.com/creekorful/foo/cmd/foo", Main: "./cmd/foo", BinName: "foo"},
+		{Alias: "github.com/creekorful/foo/cmd/bar", Main: "./cmd/bar", BinName: "bar"},
+	})
+	if len(rooted) != 2 || rooted[0].BinName != "foo" ||
+		rooted[1].Binaries[0].BinName != "cmd-foo" || rooted[1].Binaries[1].BinName != "bar" {
+		t.Errorf("wrong packages (%+v)", rooted)
+	}
+
+	// Nothing to group
+	single := groupBinaryPackages("github.com/creekorful/foo", pkgs[1:2])
+	if len(single) != 1 || single[0].Alias != "github.com/creekorful/foo/cmd/foo" {
+		t.Errorf("wrong packages (%+v)", single)
+	}
+}
+
 func runGitCmd(dir string, env []string, args ...string) error {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = dir
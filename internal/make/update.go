@@ -0,0 +1,298 @@
+package make
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/license"
+	"github.com/go-pkg-org/gopkg/internal/pkg"
+	"github.com/go-pkg-org/gopkg/internal/util"
+	"github.com/rs/zerolog/log"
+)
+
+// Update refresh an existing control package from given import path
+// to the latest upstream release, the package is left untouched if the update fails
+func Update(importPath string) (err error) {
+	directory := pkg.GetName(importPath, false)
+
+	if _, err := os.Stat(directory); err != nil {
+		return fmt.Errorf("no existing package directory: %s", directory)
+	}
+
+	config, err := config.Default()
+	if err != nil {
+		return err
+	}
+
+	m, c, err := control.ReadCtrlDirectory(directory)
+	if err != nil {
+		return err
+	}
+
+	latest, err := c.LatestRelease()
+	if err != nil {
+		return err
+	}
+	currentVersion := latest.UpstreamVersion()
+
+	// Fetch upstream source code next to the package directory
+	// so it can be moved without crossing filesystems
+	tmpDir, err := ioutil.TempDir(filepath.Dir(directory), ".gopkg-update-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	version, err := getUpstreamSource(importPath, tmpDir)
+	if err != nil {
+		return err
+	}
+	// Remove any leading v since we doesn't want it in gopkg archive
+	cleanVersion := strings.TrimPrefix(version, "v")
+
+	if cleanVersion == currentVersion {
+		log.Info().Str("import-path", importPath).Str("version", currentVersion).Msg("Package already up-to-date")
+		return nil
+	}
+
+	// Add the release first so an invalid version (f.e not newer) leaves the package untouched
+	if _, err := c.NewRelease(cleanVersion, config.GetMaintainerEntry()); err != nil {
+		return err
+	}
+	if err := c.AddChange(fmt.Sprintf("New upstream release %s", cleanVersion)); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, control.GoPkgDir)); err == nil {
+		log.Warn().Msgf("Upstream source contains a %s directory, ignoring it", control.GoPkgDir)
+	}
+
+	// Replace the upstream source code while keeping the control directory
+	// the previous sources are restored if the update fails
+	backupDir, err := ioutil.TempDir(filepath.Dir(directory), ".gopkg-update-old-")
+	if err != nil {
+		return err
+	}
+
+	if err := replaceSources(directory, tmpDir, backupDir); err != nil {
+		// The backup is only empty if the sources were restored
+		_ = os.Remove(backupDir)
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if restoreErr := restoreSources(directory, backupDir); restoreErr != nil {
+				log.Warn().Str("backup", backupDir).Str("err", restoreErr.Error()).Msg("Error while restoring sources, keeping backup")
+				return
+			}
+		}
+		if removeErr := os.RemoveAll(backupDir); removeErr != nil {
+			log.Warn().Str("err", removeErr.Error()).Msg("Error while removing sources backup")
+		}
+	}()
+
+	// Recompute the build dependencies
+	buildDepends, err := getBuildDependencies(importPath, directory)
+	if err != nil {
+		return err
+	}
+	newDeps, removedDeps := diffStrings(m.BuildDependencies, buildDepends)
+	m.BuildDependencies = buildDepends
+
+	// Fill the upstream details if missing, and warn about license change
+	if m.Description == "" {
+		if m.Description, err = getDescription(directory); err != nil {
+			return err
+		}
+	}
+	if m.Homepage == "" {
+		m.Homepage = fmt.Sprintf("https://%s", importPath)
+	}
+	licenseExpr, err := license.Detect(directory)
+	if err != nil {
+		return err
+	}
+	if m.License == "" {
+		m.License = licenseExpr
+	} else if licenseExpr != "" && licenseExpr != m.License {
+		log.Warn().Str("license", m.License).Str("detected-license", licenseExpr).Msg("Upstream license may have changed")
+	}
+
+	// Recompute the binary packages
+	binPkgs, err := getBinaryPackages(importPath, directory, config.DefaultTargets)
+	if err != nil {
+		return err
+	}
+	pkgs, newPkgs, removedPkgs := mergePackages(directory, m.Packages, withDefaultDescription(binPkgs, m.Description))
+	m.Packages = pkgs
+
+	if err := control.UpdateCtrlDirectory(directory, m, c); err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("import-path", importPath).
+		Str("previous-version", currentVersion).
+		Str("version", cleanVersion).
+		Msg("Updated package")
+	for _, alias := range newPkgs {
+		log.Info().Str("package", alias).Msg("New binary package")
+	}
+	for _, alias := range removedPkgs {
+		log.Info().Str("package", alias).Msg("Removed binary package")
+	}
+	for _, dep := range newDeps {
+		log.Info().Str("dependency", dep).Msg("New build dependency")
+	}
+	for _, dep := range removedDeps {
+		log.Info().Str("dependency", dep).Msg("Removed build dependency")
+	}
+
+	return nil
+}
+
+// replaceSources replace everything but the control directory of directory by the content of srcDir
+// the previous sources are moved into backupDir, and moved back if the replacement fails
+func replaceSources(directory, srcDir, backupDir string) error {
+	if err := moveSources(directory, backupDir); err != nil {
+		if restoreErr := moveSources(backupDir, directory); restoreErr != nil {
+			log.Warn().Str("backup", backupDir).Str("err", restoreErr.Error()).Msg("Error while restoring sources")
+		}
+		return err
+	}
+
+	if err := moveSources(srcDir, directory); err != nil {
+		if restoreErr := restoreSources(directory, backupDir); restoreErr != nil {
+			log.Warn().Str("backup", backupDir).Str("err", restoreErr.Error()).Msg("Error while restoring sources")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// restoreSources put back the sources saved by replaceSources into backupDir
+func restoreSources(directory, backupDir string) error {
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.Name() == control.GoPkgDir {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(directory, f.Name())); err != nil {
+			return err
+		}
+	}
+
+	return moveSources(backupDir, directory)
+}
+
+// moveSources move the content of srcDir but the control directory into dstDir
+func moveSources(srcDir, dstDir string) error {
+	files, err := ioutil.ReadDir(srcDir)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if f.Name() == control.GoPkgDir {
+			continue
+		}
+		if err := os.Rename(filepath.Join(srcDir, f.Name()), filepath.Join(dstDir, f.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergePackages merge the existing binary packages with the freshly detected ones
+// existing packages are kept (with their customizations) as long as their main package still exist
+// this method returns the merged packages, and the aliases of the added / removed packages
+func mergePackages(directory string, existing, detected []control.Package) ([]control.Package, []string, []string) {
+	var pkgs []control.Package
+	var added, removed []string
+
+	for _, p := range existing {
+		found := false
+		for _, d := range detected {
+			if d.Alias == p.Alias {
+				found = true
+				break
+			}
+		}
+
+		if !found && !mainsExist(directory, p) {
+			removed = append(removed, p.Alias)
+			continue
+		}
+
+		pkgs = append(pkgs, p)
+	}
+
+	for _, d := range detected {
+		found := false
+		for _, p := range existing {
+			if d.Alias == p.Alias || hasMain(p, d.Main) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			pkgs = append(pkgs, d)
+			added = append(added, d.Alias)
+		}
+	}
+
+	return pkgs, added, removed
+}
+
+// mainsExist returns true if the main packages of every binary of given package still exist
+func mainsExist(directory string, p control.Package) bool {
+	for _, binary := range p.GetBinaries() {
+		if _, err := os.Stat(filepath.Join(directory, binary.Main)); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasMain returns true if one of the binaries of given package is built from given main package
+func hasMain(p control.Package, main string) bool {
+	for _, binary := range p.GetBinaries() {
+		if path.Clean(binary.Main) == path.Clean(main) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// diffStrings returns the values added to & removed from old
+func diffStrings(old, new []string) ([]string, []string) {
+	var added, removed []string
+
+	for _, v := range new {
+		if !util.Contains(old, v) {
+			added = append(added, v)
+		}
+	}
+
+	for _, v := range old {
+		if !util.Contains(new, v) {
+			removed = append(removed, v)
+		}
+	}
+
+	return added, removed
+}
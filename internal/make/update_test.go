@@ -0,0 +1,134 @@
+package make
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+)
+
+func TestDiffStrings(t *testing.T) {
+	added, removed := diffStrings([]string{"a", "b", "c"}, []string{"b", "c", "d"})
+
+	if len(added) != 1 || added[0] != "d" {
+		t.Errorf("Wrong added values (%v)", added)
+	}
+
+	if len(removed) != 1 || removed[0] != "a" {
+		t.Errorf("Wrong removed values (%v)", removed)
+	}
+}
+
+func TestMergePackages(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, dir := range []string{"manual", "qux"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, "cmd", dir), 0750); err != nil {
+			t.Error(err)
+		}
+	}
+
+	existing := []control.Package{
+		{Alias: "foo", Main: "./cmd/foo", Description: "Foo tool"},
+		{Alias: "manual", Main: "./cmd/manual"},
+		{Alias: "old", Main: "./cmd/old"},
+		{Alias: "foo-extras", Binaries: []control.Binary{{Main: "./cmd/manual", BinName: "manual"}, {Main: "./cmd/qux", BinName: "qux"}}},
+	}
+	detected := []control.Package{
+		{Alias: "foo", Main: "./cmd/foo", Description: "TODO"},
+		{Alias: "bar", Main: "./cmd/bar", Description: "TODO"},
+		{Alias: "qux", Main: "cmd/qux", Description: "TODO"},
+	}
+
+	pkgs, added, removed := mergePackages(tmpDir, existing, detected)
+
+	if len(pkgs) != 4 {
+		t.Fatalf("Wrong number of packages (%d)", len(pkgs))
+	}
+	if pkgs[0].Alias != "foo" || pkgs[0].Description != "Foo tool" {
+		t.Errorf("Existing package should be kept (%+v)", pkgs[0])
+	}
+	if pkgs[1].Alias != "manual" {
+		t.Errorf("Manual package should be kept (%+v)", pkgs[1])
+	}
+	if pkgs[2].Alias != "foo-extras" {
+		t.Errorf("Grouped package should be kept (%+v)", pkgs[2])
+	}
+	if pkgs[3].Alias != "bar" {
+		t.Errorf("New package should be added (%+v)", pkgs[3])
+	}
+
+	if len(added) != 1 || added[0] != "bar" {
+		t.Errorf("Wrong added packages (%v)", added)
+	}
+	if len(removed) != 1 || removed[0] != "old" {
+		t.Errorf("Wrong removed packages (%v)", removed)
+	}
+}
+
+func TestReplaceSources(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		"pkg/.gopkg/metadata.yaml": "importpath: foo",
+		"pkg/.git/HEAD":            "ref",
+		"pkg/old.go":               "package foo",
+		"src/new.go":               "package foo",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	directory := filepath.Join(tmpDir, "pkg")
+	checkFiles := func(expected ...string) {
+		t.Helper()
+		for _, name := range expected {
+			if _, err := os.Stat(filepath.Join(directory, name)); err != nil {
+				t.Errorf("missing %s (%s)", name, err)
+			}
+		}
+		if entries, _ := ioutil.ReadDir(directory); len(entries) != len(expected) {
+			t.Errorf("wrong number of files (%d)", len(entries))
+		}
+	}
+
+	// The previous sources are restored if the replacement fails
+	failedBackup := filepath.Join(tmpDir, "failed-backup")
+	if err := os.Mkdir(failedBackup, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceSources(directory, filepath.Join(tmpDir, "missing"), failedBackup); err == nil {
+		t.Error("replaceSources should have failed")
+	}
+	checkFiles(".gopkg", ".git", "old.go")
+
+	backup := filepath.Join(tmpDir, "backup")
+	if err := os.Mkdir(backup, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceSources(directory, filepath.Join(tmpDir, "src"), backup); err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(".gopkg", "new.go")
+
+	if err := restoreSources(directory, backup); err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(".gopkg", ".git", "old.go")
+}
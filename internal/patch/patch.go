@@ -0,0 +1,296 @@
+package patch
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/pkg"
+	"github.com/go-pkg-org/gopkg/internal/util"
+	"github.com/rs/zerolog/log"
+)
+
+// Dir is the directory (inside the control directory) where patches are placed.
+const Dir = "patches"
+
+const seriesFile = "series"
+
+// excludedPaths are the paths never included in patches: the control files & the build artifacts
+// (f.e when building in the control directory)
+var excludedPaths = getExcludedPaths()
+
+func getExcludedPaths() []string {
+	paths := []string{":(exclude)" + control.GoPkgDir}
+	for _, pattern := range pkg.BuildArtifacts {
+		// glob magic so the patterns only match the top level files
+		paths = append(paths, ":(exclude,glob)"+pattern)
+	}
+
+	return paths
+}
+
+// ReadSeries returns the patches of the control directory at given path, in applying order
+// empty lines and lines starting with # are ignored
+func ReadSeries(path string) ([]string, error) {
+	f, err := os.Open(filepath.Join(path, control.GoPkgDir, Dir, seriesFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var patches []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Ignore patch options (f.e -p1) as done by quilt
+		patches = append(patches, strings.Fields(line)[0])
+	}
+
+	return patches, scanner.Err()
+}
+
+// Apply applies the patch series on the control directory at given path
+// if a patch does not apply, the already applied patches are reverted
+// this method returns the applied patches
+func Apply(path string) ([]string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	patches, err := ReadSeries(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []string
+	for _, p := range patches {
+		log.Debug().Str("patch", p).Msg("Applying patch")
+
+		patchFile := filepath.Join(path, control.GoPkgDir, Dir, p)
+		if _, err := runGit(path, nil, "apply", "--check", patchFile); err != nil {
+			revertApplied(path, applied)
+			return nil, fmt.Errorf("patch %s does not apply anymore, fix the sources and run `gopkg patch refresh` (%s)", p, err)
+		}
+
+		if _, err := runGit(path, nil, "apply", patchFile); err != nil {
+			revertApplied(path, applied)
+			return nil, fmt.Errorf("error while applying patch %s (%s)", p, err)
+		}
+
+		applied = append(applied, p)
+	}
+
+	return applied, nil
+}
+
+// revertApplied reverts the patches applied before a failure, errors are only reported
+func revertApplied(path string, applied []string) {
+	if err := Revert(path, applied); err != nil {
+		log.Warn().Str("err", err.Error()).Msg("Error while reverting patches")
+	}
+}
+
+// Revert reverts the given applied patches from the control directory at given path
+func Revert(path string, applied []string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	for i := len(applied) - 1; i >= 0; i-- {
+		log.Debug().Str("patch", applied[i]).Msg("Reverting patch")
+
+		patchFile := filepath.Join(path, control.GoPkgDir, Dir, applied[i])
+		if _, err := runGit(path, nil, "apply", "-R", patchFile); err != nil {
+			return fmt.Errorf("error while reverting patch %s (%s)", applied[i], err)
+		}
+	}
+
+	return nil
+}
+
+// New creates a new patch from the current changes in the control directory at given path
+// changes are computed against the upstream sources with the existing series applied
+// the patch is then appended to the series
+func New(path, name string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	patches, err := ReadSeries(path)
+	if err != nil {
+		return err
+	}
+
+	if err := checkName(name); err != nil {
+		return err
+	}
+
+	if util.Contains(patches, name) {
+		return fmt.Errorf("patch %s already exist", name)
+	}
+
+	diff, err := diff(path, patches)
+	if err != nil {
+		return err
+	}
+
+	if err := writePatch(path, name, diff); err != nil {
+		return err
+	}
+
+	// Append the patch to the series
+	f, err := os.OpenFile(filepath.Join(path, control.GoPkgDir, Dir, seriesFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintln(f, name); err != nil {
+		return err
+	}
+
+	log.Info().Str("patch", name).Msg("Successfully created patch")
+	return nil
+}
+
+// Refresh updates the given patch using the current changes in the control directory at given path
+// only the topmost patch can be refreshed, if name is empty the topmost patch is used
+func Refresh(path, name string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	patches, err := ReadSeries(path)
+	if err != nil {
+		return err
+	}
+
+	if len(patches) == 0 {
+		return fmt.Errorf("no patch to refresh")
+	}
+
+	top := patches[len(patches)-1]
+	if name == "" {
+		name = top
+	}
+	if name != top {
+		return fmt.Errorf("only the topmost patch (%s) can be refreshed", top)
+	}
+
+	diff, err := diff(path, patches[:len(patches)-1])
+	if err != nil {
+		return err
+	}
+
+	if err := writePatch(path, name, diff); err != nil {
+		return err
+	}
+
+	log.Info().Str("patch", name).Msg("Successfully refreshed patch")
+	return nil
+}
+
+// diff returns the changes made in the control directory at given path
+// against the upstream sources (git HEAD) with given patches applied
+// a temporary git index is used so the repository is left untouched
+func diff(path string, patches []string) ([]byte, error) {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+		return nil, fmt.Errorf("no upstream git repository found in %s", path)
+	}
+
+	tmpIndex, err := ioutil.TempFile("", "gopkg-index-")
+	if err != nil {
+		return nil, err
+	}
+	tmpIndex.Close()
+	defer os.Remove(tmpIndex.Name())
+
+	env := []string{fmt.Sprintf("GIT_INDEX_FILE=%s", tmpIndex.Name())}
+
+	if _, err := runGit(path, env, "read-tree", "HEAD"); err != nil {
+		return nil, err
+	}
+
+	for _, p := range patches {
+		if _, err := runGit(path, env, "apply", "--cached", filepath.Join(path, control.GoPkgDir, Dir, p)); err != nil {
+			return nil, fmt.Errorf("patch %s does not apply anymore (%s)", p, err)
+		}
+	}
+
+	// Make sure new files are part of the diff
+	if _, err := runGit(path, env, append([]string{"add", "--intent-to-add", "-A", "--", "."}, excludedPaths...)...); err != nil {
+		return nil, err
+	}
+
+	// Binary changes need the full index to be applied & reverted
+	b, err := runGit(path, env, append([]string{"diff", "--binary", "--no-color", "--no-ext-diff", "--", "."}, excludedPaths...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		return nil, fmt.Errorf("no changes found")
+	}
+
+	return b, nil
+}
+
+// checkName make sure given patch name is a plain file name that can be listed in the series
+func checkName(name string) error {
+	if name == "" || name == "." || strings.Contains(name, "..") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid patch name %q: must be a file name", name)
+	}
+	if strings.IndexFunc(name, unicode.IsSpace) != -1 {
+		return fmt.Errorf("invalid patch name %q: must not contain whitespaces", name)
+	}
+
+	return nil
+}
+
+func writePatch(path, name string, content []byte) error {
+	patchDir := filepath.Join(path, control.GoPkgDir, Dir)
+	if err := os.MkdirAll(patchDir, 0750); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(patchDir, name), content, 0640)
+}
+
+// runGit execute git with given arguments in given (absolute) directory
+// git lookup is limited to the directory, so patches apply the same way
+// when the control directory is part of another repository
+func runGit(dir string, env []string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), fmt.Sprintf("GIT_CEILING_DIRECTORIES=%s", filepath.Dir(dir)))
+	cmd.Env = append(cmd.Env, env...)
+	log.Trace().Msgf("Executing `%s`", cmd.String())
+
+	b, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return b, nil
+}
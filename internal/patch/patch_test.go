@@ -0,0 +1,183 @@
+package patch
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+)
+
+func TestReadSeries(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	patches, err := ReadSeries(tmpDir)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(patches) != 0 {
+		t.Errorf("no series should means no patches")
+	}
+
+	if err := writePatch(tmpDir, seriesFile, []byte("# comment\nfirst.patch\n\nsecond.patch -p1\n")); err != nil {
+		t.Error(err)
+	}
+
+	patches, err = ReadSeries(tmpDir)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(patches) != 2 || patches[0] != "first.patch" || patches[1] != "second.patch" {
+		t.Errorf("wrong patches (%v)", patches)
+	}
+}
+
+func TestNewApplyRevert(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := filepath.Join(tmpDir, "main.go")
+	if err := ioutil.WriteFile(file, []byte("package main\n"), 0640); err != nil {
+		t.Error(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, control.GoPkgDir), 0750); err != nil {
+		t.Error(err)
+	}
+
+	for _, args := range [][]string{{"init"}, {"add", "main.go"}, {"commit", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("error while running git %v: %s", args, err)
+		}
+	}
+
+	// Create a patch from local changes
+	if err := ioutil.WriteFile(file, []byte("package main\n\nfunc main() {}\n"), 0640); err != nil {
+		t.Error(err)
+	}
+	if err := New(tmpDir, "add-main.patch"); err != nil {
+		t.Fatal(err)
+	}
+	if err := New(tmpDir, "add-main.patch"); err == nil {
+		t.Error("patch should not be created twice")
+	}
+	if err := New(tmpDir, "../add-main.patch"); err == nil {
+		t.Error("patch should not be created outside the patches directory")
+	}
+
+	patches, err := ReadSeries(tmpDir)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(patches) != 1 || patches[0] != "add-main.patch" {
+		t.Errorf("wrong patches (%v)", patches)
+	}
+
+	// Go back to pristine sources then apply the series
+	if err := ioutil.WriteFile(file, []byte("package main\n"), 0640); err != nil {
+		t.Error(err)
+	}
+
+	applied, err := Apply(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(file); string(b) != "package main\n\nfunc main() {}\n" {
+		t.Errorf("patch not applied (%s)", b)
+	}
+
+	if err := Revert(tmpDir, applied); err != nil {
+		t.Error(err)
+	}
+	if b, _ := ioutil.ReadFile(file); string(b) != "package main\n" {
+		t.Errorf("patch not reverted (%s)", b)
+	}
+
+	// Patch no longer applying should fail cleanly
+	if err := ioutil.WriteFile(file, []byte("package foo\n"), 0640); err != nil {
+		t.Error(err)
+	}
+	if _, err := Apply(tmpDir); err == nil {
+		t.Error("patch should not apply")
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	tests := map[string]bool{
+		"fix-build.patch":  true,
+		"0001-fix.diff":    true,
+		"":                 false,
+		"../../x":          false,
+		"sub/fix.patch":    false,
+		`sub\fix.patch`:    false,
+		"fix..patch":       false,
+		"fix build.patch":  false,
+		"fix\nother.patch": false,
+		"fix\tbuild.patch": false,
+	}
+
+	for name, valid := range tests {
+		if err := checkName(name); (err == nil) != valid {
+			t.Errorf("wrong validation for %q (%v)", name, err)
+		}
+	}
+}
+
+func TestNewBinary(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := filepath.Join(tmpDir, "testdata.bin")
+	if err := ioutil.WriteFile(file, []byte{0, 1, 2, 3}, 0640); err != nil {
+		t.Error(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, control.GoPkgDir), 0750); err != nil {
+		t.Error(err)
+	}
+
+	for _, args := range [][]string{{"init"}, {"add", "testdata.bin"}, {"commit", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("error while running git %v: %s", args, err)
+		}
+	}
+
+	if err := ioutil.WriteFile(file, []byte{0, 4, 5, 6}, 0640); err != nil {
+		t.Error(err)
+	}
+	if err := New(tmpDir, "binary.patch"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Binary changes should apply & revert
+	if err := ioutil.WriteFile(file, []byte{0, 1, 2, 3}, 0640); err != nil {
+		t.Error(err)
+	}
+	applied, err := Apply(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(file); string(b) != string([]byte{0, 4, 5, 6}) {
+		t.Errorf("patch not applied (%v)", b)
+	}
+	if err := Revert(tmpDir, applied); err != nil {
+		t.Error(err)
+	}
+	if b, _ := ioutil.ReadFile(file); string(b) != string([]byte{0, 1, 2, 3}) {
+		t.Errorf("patch not reverted (%v)", b)
+	}
+}
@@ -10,7 +10,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
 // FileExt is the extension for package files
@@ -19,6 +21,24 @@ const (
 	srcSuffix = "src"
 )
 
+// ManifestFile is the file (next to the packages) listing the packages produced by a build
+const ManifestFile = "build-manifest.json"
+
+// BuildArtifacts are the name patterns of the files produced by a build next to the packages
+// (packages, build manifest, build logs & test reports)
+var BuildArtifacts = []string{"*." + FileExt, ManifestFile, "*.build.log", "*.tests.json"}
+
+// IsBuildArtifact returns true if given file name matches one of the BuildArtifacts patterns
+func IsBuildArtifact(name string) bool {
+	for _, pattern := range BuildArtifacts {
+		if match, _ := filepath.Match(pattern, name); match {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Type represent a package type
 type Type string
 
@@ -108,12 +128,23 @@ func Read(path string) (map[string][]byte, error) {
 
 // Write creates a tar file from a set of ArchiveEntries.
 func Write(path string, files []Entry, overwrite bool) error {
+	return WriteAt(path, files, overwrite, time.Unix(0, 0))
+}
+
+// WriteAt creates a tar file from a set of ArchiveEntries using given modification time.
+// Entries are sorted and headers are normalized, so the resulting archive is reproducible.
+func WriteAt(path string, files []Entry, overwrite bool, modTime time.Time) error {
 	if !overwrite {
 		if _, err := os.Stat(path); err != nil {
 			return fmt.Errorf("failed to create new tar source (file already exist)")
 		}
 	}
 
+	files = append([]Entry{}, files...)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ArchivePath < files[j].ArchivePath
+	})
+
 	var buffer bytes.Buffer
 	tw := tar.NewWriter(&buffer)
 
@@ -125,9 +156,12 @@ func Write(path string, files []Entry, overwrite bool) error {
 		}
 
 		header := &tar.Header{
-			Name: file.ArchivePath,
-			Mode: 0644,
-			Size: int64(len(fileBody)),
+			Name:    filepath.ToSlash(file.ArchivePath),
+			Mode:    0644,
+			Size:    int64(len(fileBody)),
+			ModTime: modTime.UTC().Truncate(time.Second),
+			Uid:     0,
+			Gid:     0,
 		}
 
 		if err := tw.WriteHeader(header); err != nil {
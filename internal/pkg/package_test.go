@@ -231,3 +231,21 @@ func TestParseFileName(t *testing.T) {
 		t.Errorf("wrong arch (%s)", arch)
 	}
 }
+
+func TestIsBuildArtifact(t *testing.T) {
+	tests := map[string]bool{
+		"github.com-creekorful-foo_1.0.0-1.pkg":        true,
+		"build-manifest.json":                          true,
+		"github.com-creekorful-foo_1.0.0-1.build.log":  true,
+		"github.com-creekorful-foo_1.0.0-1.tests.json": true,
+		"main.go":    false,
+		"pkg":        false,
+		"tests.json": false,
+	}
+
+	for name, expected := range tests {
+		if IsBuildArtifact(name) != expected {
+			t.Errorf("wrong artifact detection for %s (expected: %v)", name, expected)
+		}
+	}
+}
@@ -2,21 +2,33 @@ package remove
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/go-pkg-org/gopkg/internal/cache"
 	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/hook"
+	"github.com/go-pkg-org/gopkg/internal/pkg"
 	"github.com/rs/zerolog/log"
 )
 
-// Remove given package
-func Remove(pkgName string) error {
-	config, err := config.Default()
-	if err != nil {
-		return err
-	}
+// Options are the remove options
+type Options struct {
+	// Scope is where the package is installed
+	// if empty the first scope having the package installed is used
+	Scope config.Scope
+	// NoScripts disable the package hooks
+	NoScripts bool
+}
 
-	c, err := cache.Read(config.CachePath)
+// Remove given package
+// the pre-remove hook may abort the removal, a post-remove hook failure is only reported
+func Remove(pkgName string, opts Options) error {
+	config, c, err := resolveScope(pkgName, opts.Scope)
 	if err != nil {
 		return err
 	}
@@ -26,6 +38,16 @@ func Remove(pkgName string) error {
 		return fmt.Errorf("package %s is not installed", pkgName)
 	}
 
+	if dependents := getDependents(c, pkgName); len(dependents) > 0 {
+		return fmt.Errorf("package %s is required by %s", pkgName, strings.Join(dependents, ", "))
+	}
+
+	hooksDir := filepath.Join(config.HooksDir, pkg.GetName(pkgName, false))
+	hookPkg := hook.Package{Name: pkgName, Version: c.GetVersion(pkgName), BinDir: config.BinDir}
+	if err := runHook(hooksDir, control.PreRemoveHook, hookPkg, opts.NoScripts); err != nil {
+		return err
+	}
+
 	for _, file := range files {
 		if err := os.RemoveAll(file); err != nil {
 			log.Warn().Str("err", err.Error()).Str("file", file).Msg("Error while removing file")
@@ -39,6 +61,89 @@ func Remove(pkgName string) error {
 		return err
 	}
 
-	log.Info().Str("package", pkgName).Msg("Successfully removed package")
+	if err := runHook(hooksDir, control.PostRemoveHook, hookPkg, opts.NoScripts); err != nil {
+		log.Warn().Str("package", pkgName).Str("err", err.Error()).Msg("Error while running hook")
+	}
+	if err := os.RemoveAll(hooksDir); err != nil {
+		log.Warn().Str("err", err.Error()).Str("dir", hooksDir).Msg("Error while removing hooks")
+	}
+
+	log.Info().Str("package", pkgName).Str("scope", string(config.Scope)).Msg("Successfully removed package")
 	return nil
 }
+
+// resolveScope returns the configuration & installed packages of the scope where given package is installed
+// if scope is empty the scopes are searched in resolution order (project, user then system)
+func resolveScope(pkgName string, scope config.Scope) (*config.Config, *cache.Cache, error) {
+	scopes := []config.Scope{scope}
+	if scope == "" {
+		scopes = config.Scopes
+	}
+
+	for _, s := range scopes {
+		conf, err := config.ForScope(s)
+		if err != nil {
+			// The project scope is not available outside of a project
+			if scope == "" && s == config.ProjectScope {
+				log.Debug().Str("err", err.Error()).Msg("Skipping project scope")
+				continue
+			}
+			return nil, nil, err
+		}
+
+		c, err := cache.Read(conf.CachePath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if c.GetFiles(pkgName) != nil || len(scopes) == 1 {
+			return conf, c, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("package %s is not installed", pkgName)
+}
+
+// runHook run the given hook of the package if installed, unless the scripts are disabled
+func runHook(hooksDir, name string, p hook.Package, noScripts bool) error {
+	script, err := ioutil.ReadFile(filepath.Join(hooksDir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if noScripts {
+		log.Debug().Str("package", p.Name).Str("hook", name).Msg("Skipping hook")
+		return nil
+	}
+
+	return hook.Run(name, script, p)
+}
+
+// getDependents returns the installed packages whose dependencies are only satisfied by given package
+func getDependents(c *cache.Cache, pkgName string) []string {
+	var dependents []string
+	for name := range c.Packages {
+		if name == pkgName {
+			continue
+		}
+
+		for _, dep := range c.GetRelations(name).Depends {
+			d, err := control.ParseDependency(dep)
+			if err != nil {
+				continue
+			}
+
+			providers := c.Providers(d)
+			if len(providers) == 1 && providers[0] == pkgName {
+				dependents = append(dependents, name)
+				break
+			}
+		}
+	}
+	sort.Strings(dependents)
+
+	return dependents
+}
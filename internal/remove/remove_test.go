@@ -0,0 +1,212 @@
+package remove
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/go-pkg-org/gopkg/internal/cache"
+	"github.com/go-pkg-org/gopkg/internal/config"
+	"github.com/go-pkg-org/gopkg/internal/control"
+	"github.com/go-pkg-org/gopkg/internal/pkg"
+)
+
+func TestRemoveHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are not supported on windows")
+	}
+
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	conf := setConfig(t, tmpDir)
+	bin := installPackage(t, conf, "github.com/creekorful/foo", control.Relations{})
+
+	// A failing pre-remove hook aborts the removal
+	hooksDir := filepath.Join(conf.HooksDir, pkg.GetName("github.com/creekorful/foo", false))
+	writeHook(t, hooksDir, control.PreRemoveHook, "exit 1")
+	writeHook(t, hooksDir, control.PostRemoveHook, "echo \"$GOPKG_HOOK $GOPKG_PACKAGE $GOPKG_VERSION\" > "+filepath.Join(tmpDir, "post-remove"))
+
+	if err := Remove("github.com/creekorful/foo", Options{Scope: config.UserScope}); err == nil {
+		t.Error("Remove should have failed")
+	}
+	if _, err := os.Stat(bin); err != nil {
+		t.Errorf("package files should be kept (%v)", err)
+	}
+	if c, err := cache.Read(conf.CachePath); err != nil || c.GetFiles("github.com/creekorful/foo") == nil {
+		t.Errorf("package should still be installed (%v)", err)
+	}
+
+	// Hooks are run then deleted
+	writeHook(t, hooksDir, control.PreRemoveHook, "echo \"$GOPKG_HOOK\" > "+filepath.Join(tmpDir, "pre-remove"))
+	if err := Remove("github.com/creekorful/foo", Options{Scope: config.UserScope}); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, err := ioutil.ReadFile(filepath.Join(tmpDir, "pre-remove")); err != nil || string(b) != "pre-remove\n" {
+		t.Errorf("wrong pre-remove hook output (%s, %v)", b, err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(tmpDir, "post-remove")); err != nil || string(b) != "post-remove github.com/creekorful/foo 1.0.0-1\n" {
+		t.Errorf("wrong post-remove hook output (%s, %v)", b, err)
+	}
+	if _, err := os.Stat(hooksDir); !os.IsNotExist(err) {
+		t.Errorf("hooks should be deleted (%v)", err)
+	}
+	if _, err := os.Stat(bin); !os.IsNotExist(err) {
+		t.Errorf("package files should be deleted (%v)", err)
+	}
+	if c, err := cache.Read(conf.CachePath); err != nil || c.GetFiles("github.com/creekorful/foo") != nil {
+		t.Errorf("package should not be installed anymore (%v)", err)
+	}
+}
+
+func TestRemoveDependents(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	conf := setConfig(t, tmpDir)
+	protoc := installPackage(t, conf, "protoc", control.Relations{})
+	installPackage(t, conf, "protoc-gen-go", control.Relations{Depends: []string{"protoc (>= 1.0)"}})
+
+	err = Remove("protoc", Options{Scope: config.UserScope})
+	if err == nil || !strings.Contains(err.Error(), "required by protoc-gen-go") {
+		t.Errorf("wrong error (%v)", err)
+	}
+	if _, err := os.Stat(protoc); err != nil {
+		t.Errorf("package files should be kept (%v)", err)
+	}
+
+	// Removing the dependent first is fine
+	if err := Remove("protoc-gen-go", Options{Scope: config.UserScope}); err != nil {
+		t.Error(err)
+	}
+	if err := Remove("protoc", Options{Scope: config.UserScope}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetDependents(t *testing.T) {
+	c := &cache.Cache{Packages: map[string][]string{"protoc": {}, "protobuf": {}, "protoc-gen-go": {}, "grpc": {}}}
+	c.SetVersion("protoc", "3.12.0-1")
+	c.SetRelations("protobuf", control.Relations{Provides: []string{"protoc"}})
+	c.SetRelations("protoc-gen-go", control.Relations{Depends: []string{"protoc (>= 3.0)"}})
+	c.SetRelations("grpc", control.Relations{Depends: []string{"protoc"}})
+
+	// protoc-gen-go only depends on the protoc package, grpc is also satisfied by protobuf
+	if dependents := getDependents(c, "protoc"); len(dependents) != 1 || dependents[0] != "protoc-gen-go" {
+		t.Errorf("wrong dependents (%v)", dependents)
+	}
+	if dependents := getDependents(c, "protobuf"); len(dependents) != 0 {
+		t.Errorf("wrong dependents (%v)", dependents)
+	}
+}
+
+func TestResolveScope(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Outside of a project
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	setConfig(t, tmpDir)
+	prefix := filepath.Join(tmpDir, "system")
+	os.Setenv("GOPKG_SYSTEM_PREFIX", prefix)
+	defer os.Unsetenv("GOPKG_SYSTEM_PREFIX")
+
+	systemConf, err := config.ForScope(config.SystemScope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	installPackage(t, systemConf, "protoc", control.Relations{})
+
+	// The scope having the package installed is used
+	conf, c, err := resolveScope("protoc", "")
+	if err != nil || conf.Scope != config.SystemScope || c.GetFiles("protoc") == nil {
+		t.Errorf("wrong scope (%+v, %v)", conf, err)
+	}
+
+	// An explicit scope is used as is
+	if conf, _, err := resolveScope("protoc", config.UserScope); err != nil || conf.Scope != config.UserScope {
+		t.Errorf("wrong scope (%+v, %v)", conf, err)
+	}
+
+	if _, _, err := resolveScope("protoc-gen-go", ""); err == nil {
+		t.Error("resolveScope should have failed")
+	}
+	if _, _, err := resolveScope("protoc", "global"); err == nil {
+		t.Error("resolveScope should have failed")
+	}
+}
+
+// setConfig use an user scope located in given directory until the end of the test
+func setConfig(t *testing.T, dir string) *config.Config {
+	path := filepath.Join(dir, "config.yaml")
+	content := "bin_dir: " + filepath.Join(dir, "bin") + "\n" +
+		"cache_path: " + filepath.Join(dir, "cache.json") + "\n" +
+		"hooks_dir: " + filepath.Join(dir, "hooks") + "\n"
+	if err := ioutil.WriteFile(path, []byte(content), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("GOPKG_CONFIG", path)
+	t.Cleanup(func() { os.Unsetenv("GOPKG_CONFIG") })
+
+	conf, err := config.ForScope(config.UserScope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conf
+}
+
+// installPackage register an installed package (version 1.0.0-1) with a single binary
+// this method returns the binary path
+func installPackage(t *testing.T, conf *config.Config, name string, relations control.Relations) string {
+	bin := filepath.Join(conf.BinDir, filepath.Base(name))
+	if err := os.MkdirAll(conf.BinDir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(bin, []byte{}, 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := cache.Read(conf.CachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.AddPackage(name, []string{bin})
+	c.SetVersion(name, "1.0.0-1")
+	c.SetRelations(name, relations)
+	if err := cache.Write(conf.CachePath, c); err != nil {
+		t.Fatal(err)
+	}
+
+	return bin
+}
+
+func writeHook(t *testing.T, dir, name, script string) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0750); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,238 @@
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+	make2 "github.com/go-pkg-org/gopkg/internal/make"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// GitSource query upstream using the git tags
+	GitSource = "git"
+	// ProxySource query upstream using the Go module proxy
+	ProxySource = "proxy"
+
+	defaultProxy = "https://proxy.golang.org"
+)
+
+// Result is the upstream status of a control package
+type Result struct {
+	Path            string `json:"path"`
+	ImportPath      string `json:"import_path,omitempty"`
+	Version         string `json:"version,omitempty"`
+	UpstreamVersion string `json:"upstream_version,omitempty"`
+	Outdated        bool   `json:"outdated"`
+	Error           string `json:"error,omitempty"`
+}
+
+// Watch check given control directories against their upstream
+// and report the packages having newer upstream versions
+func Watch(paths []string, source string, jsonOutput bool) error {
+	if source != GitSource && source != ProxySource {
+		return fmt.Errorf("unknown upstream source: %s", source)
+	}
+
+	ctrlDirs, err := findCtrlDirectories(paths)
+	if err != nil {
+		return err
+	}
+
+	var results []Result
+	failures := 0
+	for _, ctrlDir := range ctrlDirs {
+		r := check(ctrlDir, source)
+		if r.Error != "" {
+			failures++
+		}
+		results = append(results, r)
+	}
+
+	if jsonOutput {
+		if err := writeJSON(os.Stdout, results); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range results {
+			switch {
+			case r.Error != "":
+				log.Error().Str("path", r.Path).Str("err", r.Error).Msg("Error while checking upstream")
+			case r.Outdated:
+				log.Info().
+					Str("import-path", r.ImportPath).
+					Str("version", r.Version).
+					Str("upstream-version", r.UpstreamVersion).
+					Msg("New upstream version available")
+			default:
+				log.Debug().Str("import-path", r.ImportPath).Str("version", r.Version).Msg("Package up-to-date")
+			}
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("failed to check %d package(s)", failures)
+	}
+
+	return nil
+}
+
+// writeJSON writes the JSON report of given results
+func writeJSON(w io.Writer, results []Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// check compare the control directory at given path with its upstream
+func check(path, source string) Result {
+	r := Result{Path: path}
+
+	m, c, err := control.ReadCtrlDirectory(path)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.ImportPath = m.ImportPath
+
+	latest, err := c.LatestRelease()
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.Version = latest.UpstreamVersion()
+
+	log.Debug().Str("import-path", m.ImportPath).Str("source", source).Msg("Checking upstream version")
+
+	var upstreamVersion string
+	if source == ProxySource {
+		upstreamVersion, err = getProxyVersion(m.ImportPath)
+	} else {
+		upstreamVersion, err = make2.GetUpstreamVersion(m.ImportPath)
+	}
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	r.UpstreamVersion = upstreamVersion
+	r.Outdated = control.CompareVersions(upstreamVersion, r.Version) > 0
+
+	return r
+}
+
+// findCtrlDirectories returns the control directories matching given paths
+// a path can either be a control directory, or a directory containing control directories
+func findCtrlDirectories(paths []string) ([]string, error) {
+	var ctrlDirs []string
+
+	for _, path := range paths {
+		if isCtrlDirectory(path) {
+			ctrlDirs = append(ctrlDirs, path)
+			continue
+		}
+
+		files, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, f := range files {
+			subPath := filepath.Join(path, f.Name())
+			if f.IsDir() && isCtrlDirectory(subPath) {
+				ctrlDirs = append(ctrlDirs, subPath)
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("no control directory found in %s", path)
+		}
+	}
+
+	return ctrlDirs, nil
+}
+
+func isCtrlDirectory(path string) bool {
+	info, err := os.Stat(filepath.Join(path, control.GoPkgDir))
+	return err == nil && info.IsDir()
+}
+
+// getProxyVersion returns the latest version of given module, as known by the module proxy
+func getProxyVersion(modulePath string) (string, error) {
+	proxy := defaultProxy
+	for _, p := range strings.Split(os.Getenv("GOPROXY"), ",") {
+		if strings.HasPrefix(p, "https://") || strings.HasPrefix(p, "http://") {
+			proxy = strings.TrimSuffix(p, "/")
+			break
+		}
+	}
+
+	url := fmt.Sprintf("%s/%s/@latest", proxy, escapeModulePath(modulePath))
+	log.Trace().Str("url", url).Msg("Querying module proxy")
+
+	res, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error while querying %s (status: %d)", url, res.StatusCode)
+	}
+
+	var info struct {
+		Version string
+	}
+	if err := json.NewDecoder(res.Body).Decode(&info); err != nil {
+		return "", err
+	}
+
+	// Remove any leading v since we doesn't want it in gopkg archive
+	return fromPseudoVersion(strings.TrimPrefix(info.Version, "v")), nil
+}
+
+// untaggedPseudoVersion match the pseudo-versions of untagged modules (f.e 0.0.0-20200102150405-abcdef123456)
+var untaggedPseudoVersion = regexp.MustCompile(`^0\.0\.0-(\d{14})-[0-9a-f]{12}$`)
+
+// fromPseudoVersion returns the snapshot version (as made by gopkg make) of given untagged pseudo-version
+// other versions are returned as is
+func fromPseudoVersion(version string) string {
+	m := untaggedPseudoVersion.FindStringSubmatch(version)
+	if m == nil {
+		return version
+	}
+
+	date, err := time.Parse("20060102150405", m[1])
+	if err != nil {
+		return version
+	}
+
+	return make2.SnapshotVersion(date)
+}
+
+// escapeModulePath escape given module path as required by the module proxy protocol
+// i.e every uppercase letter is replaced by an exclamation mark followed by the letter's lowercase
+func escapeModulePath(modulePath string) string {
+	var sb strings.Builder
+	for _, r := range modulePath {
+		if unicode.IsUpper(r) {
+			sb.WriteRune('!')
+			sb.WriteRune(unicode.ToLower(r))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
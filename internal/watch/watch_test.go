@@ -0,0 +1,217 @@
+package watch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-pkg-org/gopkg/internal/control"
+)
+
+func TestEscapeModulePath(t *testing.T) {
+	if p := escapeModulePath("github.com/BurntSushi/toml"); p != "github.com/!burnt!sushi/toml" {
+		t.Errorf("wrong escaped path (%s)", p)
+	}
+}
+
+func TestFindCtrlDirectories(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, dir := range []string{"foo/" + control.GoPkgDir, "bar/" + control.GoPkgDir, "baz"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0750); err != nil {
+			t.Error(err)
+		}
+	}
+
+	dirs, err := findCtrlDirectories([]string{tmpDir})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(dirs) != 2 || dirs[0] != filepath.Join(tmpDir, "bar") || dirs[1] != filepath.Join(tmpDir, "foo") {
+		t.Errorf("wrong control directories (%v)", dirs)
+	}
+
+	dirs, err = findCtrlDirectories([]string{filepath.Join(tmpDir, "foo")})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(dirs) != 1 || dirs[0] != filepath.Join(tmpDir, "foo") {
+		t.Errorf("wrong control directories (%v)", dirs)
+	}
+
+	if _, err := findCtrlDirectories([]string{filepath.Join(tmpDir, "baz")}); err == nil {
+		t.Error("directory without control directory should fail")
+	}
+}
+
+func TestFromPseudoVersion(t *testing.T) {
+	tests := map[string]string{
+		"0.0.0-20201015180534-abcdef123456":   "0.0~git202010151805",
+		"1.2.1-0.20201015180534-abcdef123456": "1.2.1-0.20201015180534-abcdef123456",
+		"1.2.0":                               "1.2.0",
+		"0.0.0-20201015180534-notacommithash": "0.0.0-20201015180534-notacommithash",
+	}
+
+	for version, expected := range tests {
+		if v := fromPseudoVersion(version); v != expected {
+			t.Errorf("wrong version for %s (got: %s want: %s)", version, v, expected)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gopkg")
+	if err != nil {
+		t.Error(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Upstream repositories: example.com/<name> is served by the local bare repository remotes/<name>.git
+	remotesDir := filepath.Join(tmpDir, "remotes")
+	createRemote(t, remotesDir, "tagged", "v1.0.0", "v1.2.0")
+	createRemote(t, remotesDir, "untagged")
+
+	gitConfig := fmt.Sprintf("[url \"%s/\"]\n\tinsteadOf = https://example.com/\n", filepath.ToSlash(remotesDir))
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, ".gitconfig"), []byte(gitConfig), 0640); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", tmpDir)
+
+	// Module proxy
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/tagged/@latest":
+			fmt.Fprint(w, `{"Version":"v1.2.0"}`)
+		case "/example.com/untagged/@latest":
+			fmt.Fprint(w, `{"Version":"v0.0.0-20201015180534-abcdef123456"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer proxy.Close()
+	defer os.Setenv("GOPROXY", os.Getenv("GOPROXY"))
+	os.Setenv("GOPROXY", proxy.URL)
+
+	tests := []struct {
+		importPath, version, source string
+		upstreamVersion             string
+		outdated                    bool
+		err                         bool
+	}{
+		{"example.com/tagged", "1.2.0", GitSource, "1.2.0", false, false},
+		{"example.com/tagged", "1.0.0", GitSource, "1.2.0", true, false},
+		{"example.com/untagged", "0.0~git202010151805", GitSource, "0.0~git202010151805", false, false},
+		{"example.com/untagged", "0.0~git2020915205", GitSource, "0.0~git202010151805", true, false},
+		{"example.com/missing", "1.0.0", GitSource, "", false, true},
+		{"example.com/tagged", "1.2.0", ProxySource, "1.2.0", false, false},
+		{"example.com/tagged", "1.0.0", ProxySource, "1.2.0", true, false},
+		{"example.com/untagged", "0.0~git202010151805", ProxySource, "0.0~git202010151805", false, false},
+		{"example.com/untagged", "0.0~git2020915205", ProxySource, "0.0~git202010151805", true, false},
+		{"example.com/missing", "1.0.0", ProxySource, "", false, true},
+	}
+
+	for i, test := range tests {
+		dir := filepath.Join(tmpDir, "packages", fmt.Sprintf("%d", i))
+		if err := control.CreateCtrlDirectory(dir, test.version, "Aloïs Micard <alois@micard.lu>", control.Metadata{ImportPath: test.importPath}); err != nil {
+			t.Fatal(err)
+		}
+
+		r := check(dir, test.source)
+		if test.err {
+			if r.Error == "" {
+				t.Errorf("check of %s %s (%s) should have failed", test.importPath, test.version, test.source)
+			}
+			continue
+		}
+
+		if r.Error != "" || r.ImportPath != test.importPath || r.Version != test.version ||
+			r.UpstreamVersion != test.upstreamVersion || r.Outdated != test.outdated {
+			t.Errorf("wrong result for %s %s (%s): %+v", test.importPath, test.version, test.source, r)
+		}
+	}
+
+	// Invalid control directory
+	dir := filepath.Join(tmpDir, "packages", "invalid")
+	if err := os.MkdirAll(filepath.Join(dir, control.GoPkgDir), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if r := check(dir, GitSource); r.Error == "" {
+		t.Errorf("check of invalid control directory should have failed (%+v)", r)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	results := []Result{
+		{Path: "foo", ImportPath: "example.com/foo", Version: "1.0.0", UpstreamVersion: "1.2.0", Outdated: true},
+		{Path: "bar", Error: "no changelog found"},
+	}
+
+	var b bytes.Buffer
+	if err := writeJSON(&b, results); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[
+  {
+    "path": "foo",
+    "import_path": "example.com/foo",
+    "version": "1.0.0",
+    "upstream_version": "1.2.0",
+    "outdated": true
+  },
+  {
+    "path": "bar",
+    "outdated": false,
+    "error": "no changelog found"
+  }
+]
+`
+	if b.String() != expected {
+		t.Errorf("wrong JSON report:\n%s", b.String())
+	}
+
+	var decoded []Result
+	if err := json.Unmarshal(b.Bytes(), &decoded); err != nil || len(decoded) != 2 || decoded[0] != results[0] {
+		t.Errorf("wrong decoded report (%v, %v)", decoded, err)
+	}
+}
+
+// createRemote creates the bare repository dir/name.git with given tags
+// the single commit is dated Thu Oct 15 20:05:34 2020 +0200
+func createRemote(t *testing.T, dir, name string, tags ...string) {
+	workDir := filepath.Join(dir, name)
+	if err := os.MkdirAll(workDir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(workDir, "README.md"), []byte("Hello, world!"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := [][]string{{"init"}, {"add", "README.md"}, {"commit", "-m", "hello"}}
+	for _, tag := range tags {
+		commands = append(commands, []string{"tag", tag})
+	}
+	commands = append(commands, []string{"clone", "--bare", workDir, filepath.Join(dir, name+".git")})
+
+	for _, args := range commands {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE=Thu Oct 15 20:05:34 2020 +0200")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("error while running git %s: %s", strings.Join(args, " "), output)
+		}
+	}
+}